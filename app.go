@@ -4,6 +4,7 @@ import (
 	"context"
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -14,7 +15,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
-	"syscall"
+	"sync/atomic"
 	"time"
 
 	goruntime "runtime"
@@ -38,54 +39,91 @@ var appIcon []byte
 
 // AudioItem represents an audio file and its settings
 type AudioItem struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	Path     string `json:"path"`
-	Hotkey   string `json:"hotkey"` // e.g., "Ctrl+Shift+A"
-	Duration string `json:"duration"`
-	Size     string `json:"size"`
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Path      string         `json:"path"`
+	Hotkey    string         `json:"hotkey"` // e.g., "Ctrl+Shift+A"
+	Duration  string         `json:"duration"`
+	Size      string         `json:"size"`
+	Effects   []EffectConfig `json:"effects,omitempty"`   // Applied in order at play time
+	Mode      string         `json:"mode,omitempty"`      // Transition when started over another clip, "" = global setting
+	Crossfade float64        `json:"crossfade,omitempty"` // Milliseconds, 0 = global setting
+	Category  string         `json:"category,omitempty"`  // Subfolder it was imported from, "a/b" style
+	Stream    bool           `json:"stream,omitempty"`    // Over the import limits: decoded from disk as it plays, not loaded into memory
 }
 
 // Config represents the application configuration
 type Config struct {
-	AudioList        []*AudioItem `json:"audio_list"`
-	CloseAction      string       `json:"close_action"` // "minimize" or "quit"
-	DontAskAgain     bool         `json:"dont_ask_again"`
-	Volume           float64      `json:"volume"`
-	MainDevice       string       `json:"main_device"` // Device ID
-	AuxDevice        string       `json:"aux_device"`  // Device ID
-	WindowWidth      int          `json:"window_width"`
-	WindowHeight     int          `json:"window_height"`
-	SidebarCollapsed bool         `json:"sidebar_collapsed"`
+	SchemaVersion    int               `json:"schema_version"` // As loaded; what is saved is always configSchemaVersion
+	AudioList        []*AudioItem      `json:"audio_list"`
+	CloseAction      string            `json:"close_action"` // "minimize" or "quit"
+	DontAskAgain     bool              `json:"dont_ask_again"`
+	Volume           float64           `json:"volume"`
+	MainDevice       string            `json:"main_device"` // Device ID
+	AuxDevice        string            `json:"aux_device"`  // Device ID
+	MainDeviceInfo   *DeviceDescriptor `json:"main_device_info,omitempty"`
+	AuxDeviceInfo    *DeviceDescriptor `json:"aux_device_info,omitempty"`
+	WindowWidth      int               `json:"window_width"`
+	WindowHeight     int               `json:"window_height"`
+	SidebarCollapsed bool              `json:"sidebar_collapsed"`
+	Output           OutputSettings    `json:"output"`
+	Backend          string            `json:"backend"` // "" = automatic, otherwise e.g. "wasapi" or "null"
+	Recording        RecordingSettings `json:"recording"`
+	Replay           ReplaySettings    `json:"replay"`
+	Voice            VoiceSettings     `json:"voice"`
+	Presets          []*EffectPreset   `json:"presets"`
+	Transport        TransportHotkeys  `json:"transport"`
+	Groups           []*ClipGroup      `json:"groups"`
+	Playback         PlaybackSettings  `json:"playback"`
+	LibraryRoot      string            `json:"library_root"` // "" = library folder next to the config
+	WatchFolders     []string          `json:"watch_folders"`
+	Import           ImportSettings    `json:"import"`
 }
 
 // AudioDevice represents an audio output device
 type AudioDevice struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	IsDefault bool          `json:"is_default"`
+	Formats   []AudioFormat `json:"formats"`
 }
 
 // App struct
 type App struct {
-	ctx       context.Context
-	Config    Config
-	mu        sync.Mutex
-	playingID string
+	ctx    context.Context
+	Config Config
+	mu     sync.Mutex
 
 	// Audio Backend
-	malCtx     *malgo.AllocatedContext
-	mainDevice *malgo.Device
-	auxDevice  *malgo.Device
-	audioMu    sync.Mutex
+	malCtx  *malgo.AllocatedContext
+	backend malgo.Backend
+	audioMu sync.Mutex // Guards device handles and formats, never taken by the audio thread
 
 	// Playback State
-	// We use beep.StreamSeeker which will be wrapped in Volume
-	mainStreamer beep.Streamer
-	auxStreamer  beep.Streamer
-	mainVolume   *effects.Volume
-	auxVolume    *effects.Volume
-
-	stopHook chan bool
+	mainBus *bus
+	auxBus  *bus
+	volume  atomic.Uint64 // math.Float64bits of Config.Volume, read by the audio thread
+
+	recorder     *recorder // Guarded by audioMu
+	trayRecord   *systray.MenuItem
+	replayDevice *malgo.Device // Guarded by audioMu
+	replay       *replayBuffer // Guarded by audioMu
+	clipRecorder *clipRecorder // Guarded by audioMu
+	mic          *micInput     // Guarded by audioMu
+	playback     atomic.Pointer[PlaybackStatus]
+	meterWake    chan struct{} // Asks the meter loop for an update now
+	folderMu     sync.Mutex    // Keeps folder imports and the watcher from adding a file twice
+
+	// Background goroutines, stopped in this order by shutdown
+	tasks        *lifecycle // Hotkey listener, tray, update download, config writer and folder watcher
+	audioTasks   *lifecycle // Engine and meter loops
+	shutdownOnce sync.Once
+
+	configStatus   ConfigStatus // Guarded by mu
+	configBackedUp bool         // Guarded by mu; a backup has been taken this session
+	configDirty    bool         // Guarded by mu; changed since last written
+	configChanged  chan struct{}
+	saveMu         sync.Mutex // Serialises config writes
 }
 
 // GitHubRelease represents the structure of GitHub Release API response
@@ -105,9 +143,10 @@ type CheckUpdateResult struct {
 	Error         string `json:"error"`
 }
 
-// StartUpdate process
+// StartUpdate downloads the new version and restarts into it. Quitting
+// meanwhile cancels the download.
 func (a *App) StartUpdate(downloadURL string) {
-	go func() {
+	a.tasks.Go(func(ctx context.Context) {
 		// Create temporary file
 		tempFile := filepath.Join(os.TempDir(), "daitoue_update.exe")
 		out, err := os.Create(tempFile)
@@ -118,7 +157,12 @@ func (a *App) StartUpdate(downloadURL string) {
 		defer out.Close()
 
 		// Download file
-		resp, err := http.Get(downloadURL)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+		if err != nil {
+			runtime.EventsEmit(a.ctx, "update-error", "下载失败: "+err.Error())
+			return
+		}
+		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
 			runtime.EventsEmit(a.ctx, "update-error", "下载失败: "+err.Error())
 			return
@@ -138,6 +182,9 @@ func (a *App) StartUpdate(downloadURL string) {
 		}
 
 		if _, err = io.Copy(out, io.TeeReader(resp.Body, counter)); err != nil {
+			if ctx.Err() != nil {
+				return // Quitting
+			}
 			runtime.EventsEmit(a.ctx, "update-error", "写入文件失败: "+err.Error())
 			return
 		}
@@ -197,7 +244,7 @@ func (a *App) StartUpdate(downloadURL string) {
 		// However, Go's os/exec does NOT automatically pass file handles (like the exe file lock) to child processes
 		// unless explicitly requested via ExtraFiles. So the child cmd.exe will NOT hold a lock on the parent exe.
 		cmd := exec.Command("cmd.exe", "/C", batPath)
-		cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+		hideWindow(cmd)
 
 		if err := cmd.Start(); err != nil {
 			runtime.EventsEmit(a.ctx, "update-error", "无法启动更新脚本: "+err.Error())
@@ -205,11 +252,15 @@ func (a *App) StartUpdate(downloadURL string) {
 		}
 
 		// Wait a bit to ensure script started
-		time.Sleep(1000 * time.Millisecond)
+		select {
+		case <-time.After(1000 * time.Millisecond):
+		case <-ctx.Done():
+			return
+		}
 
-		// Quit app
-		a.Quit()
-	}()
+		// Quit app; shutdown waits for this goroutine, so don't block it
+		go a.Quit()
+	})
 }
 
 // WriteCounter tracks download progress
@@ -315,22 +366,53 @@ func (a *App) OpenURL(url string) {
 
 // NewApp creates a new App application struct
 func NewApp() *App {
-	return &App{
+	a := &App{
 		Config: Config{
 			AudioList:    []*AudioItem{},
 			CloseAction:  "minimize", // default
 			Volume:       100,
 			WindowWidth:  900,
 			WindowHeight: 600,
+			Output: OutputSettings{
+				ResampleQuality: defaultResampleQuality,
+				LimiterCeiling:  defaultLimiterCeiling,
+			},
+			Recording: RecordingSettings{
+				Format: "wav",
+				Bus:    "aux",
+			},
+			Replay: ReplaySettings{
+				Source:  "mic",
+				Seconds: defaultReplaySeconds,
+			},
+			Presets: defaultEffectPresets(),
+			Transport: TransportHotkeys{
+				SeekStep: defaultSeekStep,
+			},
+			Playback: PlaybackSettings{
+				Mode:      transitionCut,
+				Crossfade: defaultCrossfade,
+				FadeOut:   defaultFadeOut,
+			},
+			Import: ImportSettings{
+				MaxSizeMB:  defaultMaxImportMB,
+				MaxSeconds: defaultMaxImportSeconds,
+			},
 		},
-		stopHook: make(chan bool),
+		mainBus:       newBus(),
+		auxBus:        newBus(),
+		meterWake:     make(chan struct{}, 1),
+		configChanged: make(chan struct{}, 1),
 	}
+	a.volume.Store(math.Float64bits(a.Config.Volume))
+	return a
 }
 
 // startup is called at application startup
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 	a.loadConfig()
+	a.volume.Store(math.Float64bits(a.Config.Volume))
 
 	// Adaptive window size - REMOVED per requirements (Fixed initial size 900x600)
 	if a.Config.WindowWidth > 0 && a.Config.WindowHeight > 0 {
@@ -339,17 +421,29 @@ func (a *App) startup(ctx context.Context) {
 	runtime.WindowCenter(ctx)
 	runtime.WindowShow(ctx)
 
-	// Initialize audio
+	a.tasks = newLifecycle(ctx)
+	a.audioTasks = newLifecycle(ctx)
+	a.tasks.Go(a.runConfigWriter)
+
+	// Initialize audio, with the engine loop already there to answer its commands
+	a.audioTasks.Go(a.runEngine)
 	a.initAudio()
 
 	// Start hotkey listener
-	go a.startHotkeyListener()
+	a.tasks.Go(a.startHotkeyListener)
 
-	// Start system tray
-	go func() {
+	// Push playback progress and levels to the frontend
+	a.audioTasks.Go(a.runMeters)
+
+	// Keep watched folders in sync with the list
+	a.tasks.Go(a.runFolderWatcher)
+
+	// Start system tray; it runs until shutdown
+	a.tasks.Go(func(ctx context.Context) {
 		goruntime.LockOSThread()
+		defer context.AfterFunc(ctx, systray.Quit)()
 		systray.Run(a.onTrayReady, a.onTrayExit)
-	}()
+	})
 }
 
 func (a *App) onTrayReady() {
@@ -369,6 +463,14 @@ func (a *App) onTrayReady() {
 		a.Show()
 	})
 
+	a.trayRecord = systray.AddMenuItemCheckbox("录制输出", "录制发送到麦克风的声音", false)
+	a.trayRecord.Click(func() {
+		status := a.ToggleRecording()
+		if status.Error != "" {
+			runtime.EventsEmit(a.ctx, "recording-status", status)
+		}
+	})
+
 	mQuit := systray.AddMenuItem("退出", "退出应用")
 	mQuit.Click(func() {
 		// Save window size before quit
@@ -378,28 +480,18 @@ func (a *App) onTrayReady() {
 		}
 		a.Quit()
 	})
-
-	// Keep the tray running until stopHook
-	go func() {
-		<-a.stopHook
-		systray.Quit()
-	}()
 }
 
 func (a *App) onTrayExit() {
 	// Clean up if needed
 }
 
-func (a *App) shutdown(ctx context.Context) {
-	a.stopHook <- true
-}
-
 func (a *App) beforeClose(ctx context.Context) (prevent bool) {
 	return false
 }
 
 // startHotkeyListener listens for global key events
-func (a *App) startHotkeyListener() {
+func (a *App) startHotkeyListener(ctx context.Context) {
 	keyboardChan := make(chan types.KeyboardEvent, 100)
 
 	if err := keyboard.Install(nil, keyboardChan); err != nil {
@@ -412,7 +504,7 @@ func (a *App) startHotkeyListener() {
 
 	for {
 		select {
-		case <-a.stopHook:
+		case <-ctx.Done():
 			return
 		case k := <-keyboardChan:
 			if k.Message == types.WM_KEYDOWN || k.Message == types.WM_SYSKEYDOWN {
@@ -430,6 +522,29 @@ func (a *App) checkHotkeys(pressedKeys map[uint16]bool) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	if a.Config.Replay.Hotkey != "" && a.isHotkeyPressedV2(a.Config.Replay.Hotkey, pressedKeys) {
+		go a.SaveReplay()
+		return
+	}
+
+	if a.checkTransportHotkeys(pressedKeys) {
+		return
+	}
+
+	for _, preset := range a.Config.Presets {
+		if preset.Hotkey != "" && a.isHotkeyPressedV2(preset.Hotkey, pressedKeys) {
+			go a.toggleVoicePreset(preset.ID)
+			return
+		}
+	}
+
+	for _, g := range a.Config.Groups {
+		if g.Hotkey != "" && a.isHotkeyPressedV2(g.Hotkey, pressedKeys) {
+			go a.PlayGroup(g.ID)
+			return
+		}
+	}
+
 	for _, item := range a.Config.AudioList {
 		if item.Hotkey == "" {
 			continue
@@ -484,6 +599,8 @@ func keyNameToVKCode(name string) uint16 {
 		return 9
 	case "BACKSPACE":
 		return 8
+	case "-":
+		return 189 // VK_OEM_MINUS
 	}
 
 	return 0
@@ -516,27 +633,45 @@ func (a *App) isHotkeyPressedV2(hotkey string, pressedKeys map[uint16]bool) bool
 }
 
 func (a *App) toggleAudio(id string) {
-	a.mu.Lock()
-	if a.playingID == id {
-		a.mu.Unlock()
+	if a.playingID() == id {
 		a.stopAudio()
 		return
 	}
-	a.mu.Unlock()
 	a.playAudio(id)
 }
 
+// playingID returns the clip still playing on either bus, or ""
+func (a *App) playingID() string {
+	if id := a.mainBus.playingID(); id != "" {
+		return id
+	}
+	return a.auxBus.playingID()
+}
+
+// stopAudio fades out whatever is playing and returns once the fade has begun
 func (a *App) stopAudio() {
-	a.audioMu.Lock()
-	a.mainStreamer = nil
-	a.auxStreamer = nil
-	a.mainVolume = nil
-	a.auxVolume = nil
-	a.audioMu.Unlock()
+	a.fadeOut(a.mainBus, a.auxBus)
+}
 
+// fadeOut stops what is playing on buses with the fade-out setting and
+// returns once they have started fading
+func (a *App) fadeOut(buses ...*bus) {
 	a.mu.Lock()
-	a.playingID = ""
+	fade := time.Duration(a.Config.Playback.FadeOut * float64(time.Millisecond))
 	a.mu.Unlock()
+
+	done := make([]<-chan struct{}, 0, len(buses))
+	a.audioMu.Lock()
+	for _, b := range buses {
+		done = append(done, b.send(command{kind: cmdStop, frames: int64(b.format.sampleRate.N(fade))}))
+	}
+	a.audioMu.Unlock()
+	a.await(done...)
+}
+
+// stopAudioNow cuts everything off at once and returns once both outputs are silent
+func (a *App) stopAudioNow() {
+	a.sendAll(command{kind: cmdStop})
 }
 
 func (a *App) playAudio(id string) {
@@ -549,103 +684,185 @@ func (a *App) playAudio(id string) {
 			break
 		}
 	}
-	vol := a.Config.Volume
+	var path string
+	var stream bool
+	var chain []EffectConfig
+	var t transition
+	if item != nil {
+		path, stream = item.Path, item.Stream
+		t = a.transitionFor(item)
+		// The clip's own chain first, then the global clip preset
+		chain = append(append(chain, item.Effects...), a.presetEffects(a.Config.Voice.ClipPreset)...)
+	}
 	a.mu.Unlock()
 
 	if item == nil {
 		return
 	}
+	a.startClip(id, path, stream, chain, t, true)
+}
 
-	// Stop current
-	a.stopAudio()
+// startClip decodes a clip and starts it on the main bus and, if toAux, the
+// aux bus. A streamed clip gets a reader decoding from disk for each bus
+// (see streamReader) instead of one decoded copy in memory, unless it has
+// to be reversed.
+func (a *App) startClip(id, path string, stream bool, chain []EffectConfig, t transition, toAux bool) {
+	a.mu.Lock()
+	quality := a.Config.Output.ResampleQuality
+	a.mu.Unlock()
 
-	f, err := os.Open(item.Path)
-	if err != nil {
-		runtime.LogErrorf(a.ctx, "Failed to open file: %v", err)
-		return
+	// Stop what the clip replaces; the other modes hand over once it is ready.
+	// A main-only clip (a preview) leaves the aux bus alone.
+	if t.mode != transitionCrossfade && t.mode != transitionQueue {
+		if toAux {
+			a.stopAudio()
+		} else {
+			a.fadeOut(a.mainBus)
+		}
 	}
-	defer f.Close()
 
-	var streamer beep.StreamSeekCloser
+	var src1, src2 beep.StreamSeeker
+	var closer1, closer2 io.Closer
 	var format beep.Format
-
-	ext := strings.ToLower(filepath.Ext(item.Path))
-	if ext == ".mp3" {
-		streamer, format, err = mp3.Decode(f)
-	} else if ext == ".wav" {
-		streamer, format, err = wav.Decode(f)
+	if stream && !hasEffect(chain, "reverse") {
+		streamer, f, err := openClip(path)
+		if err != nil {
+			runtime.LogErrorf(a.ctx, "Failed to open %s: %v", path, err)
+			return
+		}
+		reader := newStreamReader(streamer, f)
+		src1, closer1, format = reader, reader, f
+		if toAux {
+			if streamer, _, err = openClip(path); err != nil {
+				closer1.Close()
+				runtime.LogErrorf(a.ctx, "Failed to open %s: %v", path, err)
+				return
+			}
+			reader = newStreamReader(streamer, f)
+			src2, closer2 = reader, reader
+		}
 	} else {
-		runtime.LogErrorf(a.ctx, "Unsupported format: %s", ext)
-		return
-	}
-
-	if err != nil {
-		runtime.LogErrorf(a.ctx, "Failed to decode: %v", err)
-		return
+		buffer, err := a.decodeClip(path)
+		if err != nil {
+			runtime.LogErrorf(a.ctx, "Failed to load %s: %v", path, err)
+			return
+		}
+		if hasEffect(chain, "reverse") {
+			buffer = reverseBuffer(buffer)
+		}
+		format = buffer.Format()
+		src1 = buffer.Streamer(0, buffer.Len())
+		if toAux {
+			src2 = buffer.Streamer(0, buffer.Len())
+		}
 	}
+	// Positions and the length are in time as played, so a speed effect scales them
+	speed := chainSpeed(chain)
+	playRate := beep.SampleRate(math.Round(float64(format.SampleRate) * speed))
+	duration := format.SampleRate.D(src1.Len()).Seconds() / speed
 
-	// Buffer needed for multiple streams
-	buffer := beep.NewBuffer(format)
-	buffer.Append(streamer)
-	streamer.Close()
-
-	// Create streamers from buffer
-	s1 := buffer.Streamer(0, buffer.Len())
-	s2 := buffer.Streamer(0, buffer.Len())
-
-	var finalS1, finalS2 beep.Streamer
+	// Resample to each device's negotiated rate (they may differ)
+	a.audioMu.Lock()
+	mainRate, auxRate := a.mainBus.format.sampleRate, a.auxBus.format.sampleRate
+	a.audioMu.Unlock()
 
-	// Resample if needed
-	if format.SampleRate != 44100 {
-		finalS1 = beep.Resample(4, format.SampleRate, 44100, s1)
-		finalS2 = beep.Resample(4, format.SampleRate, 44100, s2)
-	} else {
-		finalS1 = s1
-		finalS2 = s2
+	// Effects (each bus needs its own chain, they keep state), then volume
+	voiceChain := func(src *clipSource, rate beep.SampleRate) beep.Streamer {
+		s := resampleTo(quality, format.SampleRate, rate, src)
+		s = buildEffectChain(s, chain, rate, quality)
+		return &liveVolume{Volume: effects.Volume{Streamer: s, Base: 2}, app: a}
+	}
+	s1 := newClipSource(src1)
+	v1 := voiceChain(s1, mainRate)
+	var s2 *clipSource
+	var v2 beep.Streamer
+	if toAux {
+		s2 = newClipSource(src2)
+		v2 = voiceChain(s2, auxRate)
 	}
 
-	// Volume
-	v1 := &effects.Volume{Streamer: finalS1, Base: 2, Volume: a.calculateVolume(vol), Silent: vol == 0}
-	v2 := &effects.Volume{Streamer: finalS2, Base: 2, Volume: a.calculateVolume(vol), Silent: vol == 0}
-
+	// Wait until the clip is on the buses, so playingID agrees straight away
+	done := make([]<-chan struct{}, 0, 2)
 	a.audioMu.Lock()
-	a.mainStreamer = v1
-	a.auxStreamer = v2
-	a.mainVolume = v1
-	a.auxVolume = v2
+	done = append(done, a.mainBus.send(command{
+		kind:   cmdStart,
+		voice:  &voice{id: id, streamer: v1, source: s1, rate: playRate, duration: duration, closer: closer1},
+		mode:   t.mode,
+		frames: int64(t.frames(mainRate)),
+	}))
+	if toAux {
+		done = append(done, a.auxBus.send(command{
+			kind:   cmdStart,
+			voice:  &voice{id: id, streamer: v2, source: s2, rate: playRate, duration: duration, closer: closer2},
+			mode:   t.mode,
+			frames: int64(t.frames(auxRate)),
+		}))
+	}
 	a.audioMu.Unlock()
+	a.await(done...)
+}
 
-	a.mu.Lock()
-	a.playingID = id
-	a.mu.Unlock()
+// decodeClip reads a whole clip into memory
+func (a *App) decodeClip(path string) (*beep.Buffer, error) {
+	streamer, format, err := openClip(path)
+	if err != nil {
+		return nil, err
+	}
+	defer streamer.Close()
+
+	// Buffer needed for multiple streams
+	buffer := beep.NewBuffer(format)
+	buffer.Append(streamer)
+	return buffer, nil
 }
 
 // initAudio initializes malgo context
 func (a *App) initAudio() {
+	a.mu.Lock()
+	chosen := a.Config.Backend
+	a.mu.Unlock()
+
+	backends := platformBackends()
+	if chosen != "" {
+		if b, ok := parseBackend(chosen); ok {
+			// Still fall back to the automatic order if the chosen one is unavailable
+			backends = append([]malgo.Backend{b}, backends...)
+		} else {
+			runtime.LogErrorf(a.ctx, "Unknown audio backend %q, using automatic selection", chosen)
+		}
+	}
+
 	var err error
-	a.malCtx, err = malgo.InitContext(nil, malgo.ContextConfig{}, func(message string) {
-		// fmt.Printf("MALGO: %v\n", message)
-	})
+	// Try one backend at a time so we know which one we ended up with;
+	// device IDs are only meaningful within their backend.
+	for _, backend := range backends {
+		a.malCtx, err = malgo.InitContext([]malgo.Backend{backend}, malgo.ContextConfig{}, func(message string) {
+			// fmt.Printf("MALGO: %v\n", message)
+		})
+		if err == nil {
+			a.backend = backend
+			break
+		}
+		runtime.LogErrorf(a.ctx, "Audio backend %s unavailable: %v", backendName(backend), err)
+	}
 	if err != nil {
 		runtime.LogErrorf(a.ctx, "Failed to init malgo context: %v", err)
 		return
 	}
 	a.restartAudioDevices()
+	a.restartReplay()
 }
 
 func (a *App) restartAudioDevices() {
+	// The bus format may change, so a running recording can't continue
+	a.StopRecording()
+
 	// 1. Capture old devices and stop playback
 	a.audioMu.Lock()
-	oldMain := a.mainDevice
-	oldAux := a.auxDevice
-	a.mainDevice = nil
-	a.auxDevice = nil
-
-	// Reset streamers to avoid playing old buffer on new device
-	a.mainStreamer = nil
-	a.auxStreamer = nil
-	a.mainVolume = nil
-	a.auxVolume = nil
+	oldMain := a.mainBus.device
+	oldAux := a.auxBus.device
+	a.mainBus.device = nil
+	a.auxBus.device = nil
 	a.audioMu.Unlock()
 
 	// 2. Stop old devices (safe to do outside lock)
@@ -656,265 +873,319 @@ func (a *App) restartAudioDevices() {
 		oldAux.Uninit()
 	}
 
-	// 3. Prepare Config
-	deviceConfig := malgo.DefaultDeviceConfig(malgo.Playback)
-	deviceConfig.Playback.Format = malgo.FormatF32
-	deviceConfig.Playback.Channels = 2
-	deviceConfig.SampleRate = 44100
-	deviceConfig.Alsa.NoMMap = 1
+	// Drop the voices so the old clip doesn't carry on on the new device
+	a.mainBus.reset()
+	a.auxBus.reset()
+	a.mainBus.period.Store(0)
+	a.auxBus.period.Store(0)
 
-	// 4. Init Main Device
-	// IMPORTANT: We need a fresh config copy for each InitDevice call if we modify it
-	mainConfig := deviceConfig
-	mainID := a.findDeviceID(a.Config.MainDevice)
-
-	if mainID != nil {
-		mainConfig.Playback.DeviceID = mainID.Pointer()
-	} else {
-		// If configured device not found, fallback to default?
-		// Or if Config.MainDevice is "default", use nil.
-		// If Config.MainDevice is some ID but not found (e.g. unplugged), we should probably fallback to default.
-		if a.Config.MainDevice != "" && a.Config.MainDevice != "default" {
-			runtime.LogErrorf(a.ctx, "Main device %s not found, falling back to default", a.Config.MainDevice)
-		}
-		mainConfig.Playback.DeviceID = nil
+	if a.malCtx == nil {
+		return // Audio backend failed to initialise
 	}
 
-	onRecvMain := func(pOutput, pInput []byte, framecount uint32) {
-		a.onSamples(pOutput, pInput, framecount, false)
-	}
+	a.mu.Lock()
+	mainSel, mainDesc := a.Config.MainDevice, a.Config.MainDeviceInfo
+	auxSel, auxDesc := a.Config.AuxDevice, a.Config.AuxDeviceInfo
+	settings := a.Config.Output
+	a.mu.Unlock()
 
-	var err error
-	var newMain *malgo.Device
-	newMain, err = malgo.InitDevice(a.malCtx.Context, mainConfig, malgo.DeviceCallbacks{
-		Data: onRecvMain,
-	})
+	// 3. Init Main Device
+	mainInfo := a.resolveDevice(mainSel, mainDesc)
+	if mainInfo == nil && isDeviceSelection(mainSel) {
+		// If Config.MainDevice is some ID but not found (e.g. unplugged), fall back to default.
+		runtime.LogErrorf(a.ctx, "Main device %s not found, falling back to default", mainSel)
+	}
 
-	if err == nil {
-		err = newMain.Start()
-		if err != nil {
-			runtime.LogErrorf(a.ctx, "Failed to start main device: %v", err)
-		}
-	} else {
-		runtime.LogErrorf(a.ctx, "Failed to init main device: %v", err)
+	newMain, mainFormat, err := a.openPlayback(mainInfo, settings, a.mainBus)
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to open main device: %v", err)
 	}
 
-	// 5. Init Aux Device
+	// 4. Init Aux Device
 	var newAux *malgo.Device
-	if a.Config.AuxDevice != "" && a.Config.AuxDevice != "none" {
-		auxID := a.findDeviceID(a.Config.AuxDevice)
-		if auxID != nil {
-			auxConfig := deviceConfig
-			auxConfig.Playback.DeviceID = auxID.Pointer()
-
-			onRecvAux := func(pOutput, pInput []byte, framecount uint32) {
-				a.onSamples(pOutput, pInput, framecount, true)
-			}
-
-			newAux, err = malgo.InitDevice(a.malCtx.Context, auxConfig, malgo.DeviceCallbacks{
-				Data: onRecvAux,
-			})
-			if err == nil {
-				err = newAux.Start()
-				if err != nil {
-					runtime.LogErrorf(a.ctx, "Failed to start aux device: %v", err)
-				}
-			} else {
-				runtime.LogErrorf(a.ctx, "Failed to init aux device: %v", err)
+	var auxFormat outputFormat
+	auxInfo := a.resolveDevice(auxSel, auxDesc)
+	if isDeviceSelection(auxSel) {
+		if auxInfo != nil {
+			newAux, auxFormat, err = a.openPlayback(auxInfo, settings, a.auxBus)
+			if err != nil {
+				runtime.LogErrorf(a.ctx, "Failed to open aux device: %v", err)
 			}
+		} else {
+			runtime.LogErrorf(a.ctx, "Aux device %s not found", auxSel)
 		}
 	}
 
-	// 6. Update references
+	// 5. Update references
 	a.audioMu.Lock()
-	a.mainDevice = newMain
-	a.auxDevice = newAux
+	a.mainBus.device = newMain
+	a.auxBus.device = newAux
+	a.mainBus.format = mainFormat
+	a.auxBus.format = auxFormat
 	a.audioMu.Unlock()
-}
 
-func (a *App) findDeviceID(idStr string) *malgo.DeviceID {
-	if idStr == "" || idStr == "default" || idStr == "none" {
-		return nil
+	runtime.EventsEmit(a.ctx, "audio-status", a.GetAudioStatus())
+
+	// 6. Refresh stored descriptors, following the device if its ID changed
+	var newMainDesc, newAuxDesc *DeviceDescriptor
+	if mainInfo != nil {
+		newMainDesc = a.describeDevice(mainInfo)
 	}
-	infos, err := a.malCtx.Devices(malgo.Playback)
-	if err != nil {
-		return nil
+	if auxInfo != nil {
+		newAuxDesc = a.describeDevice(auxInfo)
 	}
-	for i := range infos {
-		if infos[i].ID.String() == idStr {
-			return &infos[i].ID
-		}
+
+	a.mu.Lock()
+	changed := false
+	// Skip if the user picked something else while we were restarting
+	if newMainDesc != nil && a.Config.MainDevice == mainSel &&
+		(a.Config.MainDeviceInfo == nil || *a.Config.MainDeviceInfo != *newMainDesc) {
+		a.Config.MainDevice = newMainDesc.ID
+		a.Config.MainDeviceInfo = newMainDesc
+		changed = true
+	}
+	if newAuxDesc != nil && a.Config.AuxDevice == auxSel &&
+		(a.Config.AuxDeviceInfo == nil || *a.Config.AuxDeviceInfo != *newAuxDesc) {
+		a.Config.AuxDevice = newAuxDesc.ID
+		a.Config.AuxDeviceInfo = newAuxDesc
+		changed = true
 	}
-	return nil
+	if changed {
+		a.saveConfig()
+	}
+	a.mu.Unlock()
+
+	// The pass-through follows the aux device and its rate
+	a.restartVoice()
 }
 
-func (a *App) onSamples(pOutput, pInput []byte, framecount uint32, isAux bool) {
-	a.audioMu.Lock()
-	var s beep.Streamer
-	if isAux {
-		s = a.auxStreamer
-	} else {
-		s = a.mainStreamer
+// Frontend Methods
+
+// ImportAudioFile asks for audio files and imports them. Returns nil if cancelled.
+func (a *App) ImportAudioFile() *ImportReport {
+	paths, err := runtime.OpenMultipleFilesDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Select Audio Files",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Audio Files", Pattern: "*.mp3;*.wav"},
+		},
+	})
+	if err != nil || len(paths) == 0 {
+		return nil
 	}
+	report := a.ImportAudioFiles(paths)
+	return &report
+}
 
-	if s == nil {
-		a.audioMu.Unlock()
-		// Zero buffer
-		for i := range pOutput {
-			pOutput[i] = 0
-		}
-		return
+// ImportAudioFiles imports the given files, reporting on each
+func (a *App) ImportAudioFiles(paths []string) ImportReport {
+	report := ImportReport{Entries: []ImportEntry{}}
+	for _, path := range paths {
+		_, entry := a.importAudioFile(path)
+		report.add(entry)
 	}
 
-	// Prepare buffer
-	mixSamples := make([][2]float64, framecount)
-	n, ok := s.Stream(mixSamples)
+	if report.Added > 0 {
+		a.mu.Lock()
+		a.saveConfig()
+		a.mu.Unlock()
+	}
+	return report
+}
 
-	a.audioMu.Unlock() // Unlock after reading
+// ImportSettings are the limits imported files are checked against
+type ImportSettings struct {
+	MaxSizeMB      float64 `json:"max_size_mb"`      // 0 = no limit
+	MaxSeconds     float64 `json:"max_seconds"`      // 0 = no limit
+	AllowOverLimit bool    `json:"allow_over_limit"` // Import bigger files anyway, streamed from disk when played
+}
 
-	// Process samples
-	for i := 0; i < n; i++ {
-		// Left
-		sample := float32(mixSamples[i][0])
-		u := math.Float32bits(sample)
-		pOutput[i*8+0] = byte(u)
-		pOutput[i*8+1] = byte(u >> 8)
-		pOutput[i*8+2] = byte(u >> 16)
-		pOutput[i*8+3] = byte(u >> 24)
+// Default import limits
+const (
+	defaultMaxImportMB      = 10
+	defaultMaxImportSeconds = 100
+)
 
-		// Right
-		sample = float32(mixSamples[i][1])
-		u = math.Float32bits(sample)
-		pOutput[i*8+4] = byte(u)
-		pOutput[i*8+5] = byte(u >> 8)
-		pOutput[i*8+6] = byte(u >> 16)
-		pOutput[i*8+7] = byte(u >> 24)
-	}
+// AudioInfo is what probing an audio file measured
+type AudioInfo struct {
+	Format     string  `json:"format"` // "mp3" or "wav"
+	Seconds    float64 `json:"seconds"`
+	Bytes      int64   `json:"bytes"`
+	SampleRate int     `json:"sample_rate"`
+	Channels   int     `json:"channels"`
+}
 
-	// Zero the rest
-	for i := n * 8; i < len(pOutput); i++ {
-		pOutput[i] = 0
-	}
+// Reason codes for import entries that failed or came with a warning
+const (
+	reasonNotFound     = "not_found"
+	reasonUnreadable   = "unreadable"
+	reasonUnsupported  = "unsupported_format"
+	reasonDecodeFailed = "decode_failed"
+	reasonTooLarge     = "too_large"
+	reasonTooLong      = "too_long"
+	reasonStreamed     = "streamed" // Over the limits, imported anyway and streamed from disk
+)
 
-	if !ok || n < len(mixSamples) {
-		a.audioMu.Lock()
-		if isAux {
-			a.auxStreamer = nil
-			a.auxVolume = nil
-		} else {
-			a.mainStreamer = nil
-			a.mainVolume = nil
-			if a.playingID != "" {
-				// Only clear if main finished?
-				// Actually UI doesn't track playing state much except notification.
-			}
-		}
-		a.audioMu.Unlock()
+// importError is why a file can't be imported, with a reason code for the frontend
+type importError struct {
+	reason  string
+	message string
+}
 
-		if !isAux {
-			a.mu.Lock()
-			a.playingID = ""
-			a.mu.Unlock()
-		}
+func (e *importError) Error() string {
+	return e.message
+}
+
+// importReason returns the reason code of an import error
+func importReason(err error) string {
+	var ie *importError
+	if errors.As(err, &ie) {
+		return ie.reason
 	}
+	return reasonUnreadable
 }
 
-// Frontend Methods
+// check returns an error if info is over the limits
+func (s ImportSettings) check(info AudioInfo) error {
+	if s.MaxSizeMB > 0 && float64(info.Bytes) > s.MaxSizeMB*1024*1024 {
+		return &importError{reasonTooLarge, fmt.Sprintf("文件过大 (>%gMB)", s.MaxSizeMB)}
+	}
+	if s.MaxSeconds > 0 && info.Seconds > s.MaxSeconds {
+		return &importError{reasonTooLong, fmt.Sprintf("时长过长 (>%gs)", s.MaxSeconds)}
+	}
+	return nil
+}
 
-func (a *App) ImportAudioFile() string {
-	paths, err := runtime.OpenMultipleFilesDialog(a.ctx, runtime.OpenDialogOptions{
-		Title: "Select Audio Files",
-		Filters: []runtime.FileFilter{
-			{DisplayName: "Audio Files", Pattern: "*.mp3;*.wav"},
-		},
-	})
-	if err != nil || len(paths) == 0 {
-		return ""
+// GetImportSettings returns the import limits
+func (a *App) GetImportSettings() ImportSettings {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.Config.Import
+}
+
+// SetImportSettings changes the import limits; files already imported keep
+// their place. The replay buffer is shortened to fit if need be.
+func (a *App) SetImportSettings(settings ImportSettings) {
+	settings.MaxSizeMB = max(settings.MaxSizeMB, 0)
+	settings.MaxSeconds = max(settings.MaxSeconds, 0)
+
+	a.mu.Lock()
+	a.Config.Import = settings
+	replay := a.Config.Replay.normalize(settings)
+	resized := replay != a.Config.Replay
+	a.Config.Replay = replay
+	a.saveConfig()
+	a.mu.Unlock()
+
+	if resized {
+		a.restartReplay()
 	}
-	return a.ImportAudioFiles(paths)
 }
 
-func (a *App) ImportAudioFiles(paths []string) string {
-	var results []string
-	var addedCount int
+// importAudioFile validates one file against the import settings and appends
+// it to the audio list, returning the new item (nil if it failed) and an
+// entry saying how it went. The caller is responsible for saving the config.
+func (a *App) importAudioFile(path string) (*AudioItem, ImportEntry) {
+	entry := ImportEntry{Name: filepath.Base(path), Path: path, Status: importAdded}
+	info, err := probeAudioFile(path)
+	if err != nil {
+		entry.Status, entry.Reason, entry.Message = importFailed, importReason(err), err.Error()
+		return nil, entry
+	}
+	entry.Info = &info
 
-	for _, path := range paths {
-		// Check limits
-		info, err := os.Stat(path)
-		if err != nil {
-			results = append(results, fmt.Sprintf("Error (%s): %s", filepath.Base(path), err.Error()))
-			continue
-		}
-		if info.Size() > 10*1024*1024 { // 10MB
-			results = append(results, fmt.Sprintf("Error (%s): 文件过大 (>10MB)", filepath.Base(path)))
-			continue
-		}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// Over the limits it is either refused or streamed instead of loaded into memory
+	err = a.Config.Import.check(info)
+	if err != nil && !a.Config.Import.AllowOverLimit {
+		entry.Status, entry.Reason, entry.Message = importFailed, importReason(err), err.Error()
+		return nil, entry
+	}
+	if err != nil {
+		entry.Status, entry.Reason, entry.Message = importWarning, reasonStreamed, err.Error()+"，将从磁盘播放"
+	}
 
-		// Check duration
-		f, err := os.Open(path)
-		if err != nil {
-			results = append(results, fmt.Sprintf("Error (%s): 无法打开文件", filepath.Base(path)))
-			continue
-		}
+	// Add to list
+	item := a.newAudioItem(path, info)
+	item.Stream = err != nil
+	a.Config.AudioList = append(a.Config.AudioList, item)
+	entry.ID = item.ID
+	return item, entry
+}
 
-		var duration time.Duration
-		var decodeErr error
-		ext := strings.ToLower(filepath.Ext(path))
-
-		if ext == ".mp3" {
-			streamer, format, err := mp3.Decode(f)
-			if err == nil {
-				duration = format.SampleRate.D(streamer.Len())
-				streamer.Close()
-			} else {
-				decodeErr = err
-			}
-		} else if ext == ".wav" {
-			streamer, format, err := wav.Decode(f)
-			if err == nil {
-				duration = format.SampleRate.D(streamer.Len())
-				streamer.Close()
-			} else {
-				decodeErr = err
-			}
+// openClip opens an audio file for decoding. Closing the streamer closes the file.
+func openClip(path string) (beep.StreamSeekCloser, beep.Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, beep.Format{}, &importError{reasonNotFound, "文件不存在"}
 		}
+		return nil, beep.Format{}, &importError{reasonUnreadable, "无法打开文件"}
+	}
+
+	var streamer beep.StreamSeekCloser
+	var format beep.Format
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".mp3" {
+		streamer, format, err = mp3.Decode(f)
+	} else if ext == ".wav" {
+		streamer, format, err = wav.Decode(f)
+	} else {
 		f.Close()
+		return nil, beep.Format{}, &importError{reasonUnsupported, "不支持的格式"}
+	}
+	if err != nil {
+		f.Close()
+		return nil, beep.Format{}, &importError{reasonDecodeFailed, "解码失败"}
+	}
+	return fileStreamer{streamer, f}, format, nil
+}
 
-		if decodeErr != nil {
-			results = append(results, fmt.Sprintf("Error (%s): 解码失败", filepath.Base(path)))
-			continue
-		}
+// fileStreamer closes the file under a decoder along with it
+type fileStreamer struct {
+	beep.StreamSeekCloser
+	f *os.File
+}
 
-		if duration.Seconds() > 100 {
-			results = append(results, fmt.Sprintf("Error (%s): 时长过长 (>100s)", filepath.Base(path)))
-			continue
-		}
+func (s fileStreamer) Close() error {
+	err := s.StreamSeekCloser.Close()
+	s.f.Close()
+	return err
+}
 
-		// Add to list
-		item := &AudioItem{
-			ID:       fmt.Sprintf("%d_%d", time.Now().UnixNano(), len(a.Config.AudioList)),
-			Name:     filepath.Base(path),
-			Path:     path,
-			Duration: fmt.Sprintf("%.1fs", duration.Seconds()),
-			Size:     fmt.Sprintf("%.2fMB", float64(info.Size())/1024/1024),
+// probeAudioFile measures a file; checking it against the limits is up to the caller
+func probeAudioFile(path string) (AudioInfo, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return AudioInfo{}, &importError{reasonNotFound, "文件不存在"}
 		}
-
-		a.mu.Lock()
-		a.Config.AudioList = append(a.Config.AudioList, item)
-		a.mu.Unlock()
-		addedCount++
+		return AudioInfo{}, &importError{reasonUnreadable, "无法读取文件"}
 	}
 
-	if addedCount > 0 {
-		a.mu.Lock()
-		a.saveConfig()
-		a.mu.Unlock()
-	}
+	streamer, format, err := openClip(path)
+	if err != nil {
+		return AudioInfo{}, err
+	}
+	defer streamer.Close()
+	return AudioInfo{
+		Format:     strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), "."),
+		Seconds:    format.SampleRate.D(streamer.Len()).Seconds(),
+		Bytes:      stat.Size(),
+		SampleRate: int(format.SampleRate),
+		Channels:   format.NumChannels,
+	}, nil
+}
 
-	if len(results) > 0 {
-		return strings.Join(results, "\n")
+// newAudioItem makes a list entry with a fresh ID for a probed file. Caller holds a.mu.
+func (a *App) newAudioItem(path string, info AudioInfo) *AudioItem {
+	return &AudioItem{
+		ID:       fmt.Sprintf("%d_%d", time.Now().UnixNano(), len(a.Config.AudioList)),
+		Name:     filepath.Base(path),
+		Path:     path,
+		Duration: fmt.Sprintf("%.1fs", info.Seconds),
+		Size:     fmt.Sprintf("%.2fMB", float64(info.Bytes)/1024/1024),
 	}
-	return "OK"
 }
 
 func (a *App) GetAudios() []AudioItem {
@@ -936,6 +1207,7 @@ func (a *App) DeleteAudio(id string) {
 			break
 		}
 	}
+	a.removeFromGroups(id)
 	a.saveConfig()
 }
 
@@ -987,6 +1259,38 @@ func (a *App) UpdateHotkey(id string, hotkey string) {
 	a.saveConfig() // Ensure saveConfig is called
 }
 
+// SetAudioEffects saves a clip's effect chain
+func (a *App) SetAudioEffects(id string, chain []EffectConfig) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, item := range a.Config.AudioList {
+		if item.ID == id {
+			item.Effects = chain
+			break
+		}
+	}
+	a.saveConfig()
+}
+
+// PreviewAudioEffects plays a clip with an unsaved effect chain, on the main device only
+func (a *App) PreviewAudioEffects(id string, chain []EffectConfig) {
+	a.mu.Lock()
+	var path string
+	var stream bool
+	for _, item := range a.Config.AudioList {
+		if item.ID == id {
+			path, stream = item.Path, item.Stream
+			break
+		}
+	}
+	a.mu.Unlock()
+
+	if path == "" {
+		return
+	}
+	a.startClip(id, path, stream, chain, transition{mode: transitionCut}, false)
+}
+
 func (a *App) PlayAudioID(id string) {
 	a.toggleAudio(id)
 }
@@ -1071,10 +1375,12 @@ func (a *App) GetAudioDevices() []AudioDevice {
 	}
 
 	var devices []AudioDevice
-	for _, info := range infos {
+	for i := range infos {
 		devices = append(devices, AudioDevice{
-			ID:   info.ID.String(),
-			Name: info.Name(),
+			ID:        infos[i].ID.String(),
+			Name:      infos[i].Name(),
+			IsDefault: infos[i].IsDefault != 0,
+			Formats:   toAudioFormats(a.deviceFormats(&infos[i])),
 		})
 	}
 	return devices
@@ -1083,52 +1389,123 @@ func (a *App) GetAudioDevices() []AudioDevice {
 func (a *App) SetAudioSettings(mainID, auxID string, volume float64) {
 	a.mu.Lock()
 	changed := (mainID != a.Config.MainDevice) || (auxID != a.Config.AuxDevice)
+	// A new selection invalidates the old descriptor; restartAudioDevices fills in the new one
+	if mainID != a.Config.MainDevice {
+		a.Config.MainDeviceInfo = nil
+	}
+	if auxID != a.Config.AuxDevice {
+		a.Config.AuxDeviceInfo = nil
+	}
 	a.Config.MainDevice = mainID
 	a.Config.AuxDevice = auxID
 	a.Config.Volume = volume
 	a.saveConfig()
 	a.mu.Unlock()
 
+	// Playing voices pick this up at their next buffer
+	a.volume.Store(math.Float64bits(volume))
+
 	if changed {
 		a.restartAudioDevices()
-	} else {
-		// Just update volume
-		a.audioMu.Lock()
-		if a.mainVolume != nil {
-			a.mainVolume.Volume = a.calculateVolume(volume)
-			a.mainVolume.Silent = (volume == 0)
+	}
+}
+
+// GetOutputSettings returns the advanced output settings
+func (a *App) GetOutputSettings() OutputSettings {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.Config.Output
+}
+
+// SetOutputSettings stores the advanced output settings and reopens the devices with them
+func (a *App) SetOutputSettings(settings OutputSettings) {
+	a.mu.Lock()
+	a.Config.Output = settings.normalize()
+	a.saveConfig()
+	a.mu.Unlock()
+
+	a.restartAudioDevices()
+}
+
+// GetAudioStatus reports the sample rate, channels and buffer size each device actually negotiated
+func (a *App) GetAudioStatus() AudioStatus {
+	a.audioMu.Lock()
+	defer a.audioMu.Unlock()
+
+	status := AudioStatus{
+		Main: a.mainBus.status(),
+		Aux:  a.auxBus.status(),
+	}
+	if a.malCtx != nil {
+		status.Backend = backendName(a.backend)
+	}
+	return status
+}
+
+// GetAudioBackends lists the backends that can be initialised on this machine.
+// "null" is always available and outputs nothing (useful for headless runs).
+func (a *App) GetAudioBackends() []string {
+	names := []string{}
+	for _, backend := range selectableBackends() {
+		if backend == backendNull || probeBackend(backend) {
+			names = append(names, backendName(backend))
 		}
-		if a.auxVolume != nil {
-			a.auxVolume.Volume = a.calculateVolume(volume)
-			a.auxVolume.Silent = (volume == 0)
+	}
+	return names
+}
+
+// SetAudioBackend switches the audio backend ("" for automatic) and re-initializes audio
+func (a *App) SetAudioBackend(name string) {
+	if name != "" {
+		if _, ok := parseBackend(name); !ok {
+			runtime.LogErrorf(a.ctx, "Unknown audio backend %q", name)
+			return
 		}
-		a.audioMu.Unlock()
 	}
+
+	a.mu.Lock()
+	a.Config.Backend = name
+	a.saveConfig()
+	a.mu.Unlock()
+
+	a.closeAudio()
+	a.initAudio()
 }
 
 // ResetAudio completely re-initializes the audio context and devices
 func (a *App) ResetAudio() {
 	// 1. Stop Playback
-	a.stopAudio()
+	a.stopAudioNow()
 
 	// 2. Reset Config to defaults
 	a.mu.Lock()
 	a.Config.MainDevice = "" // Default
 	a.Config.AuxDevice = ""  // None
+	a.Config.MainDeviceInfo = nil
+	a.Config.AuxDeviceInfo = nil
+	a.Config.Backend = "" // Automatic
 	a.saveConfig()
 	a.mu.Unlock()
 
-	// 3. Stop Devices safely (copied from restartAudioDevices logic)
+	// 3. Stop Devices safely
+	a.closeAudio()
+
+	// 4. Re-init
+	a.initAudio()
+}
+
+// closeAudio stops both devices and releases the malgo context
+func (a *App) closeAudio() {
+	a.StopRecording()
+	a.stopReplay()
+	a.CancelClipRecording()
+	a.stopVoice()
+
 	a.audioMu.Lock()
-	oldMain := a.mainDevice
-	oldAux := a.auxDevice
-	a.mainDevice = nil
-	a.auxDevice = nil
-	// Reset streamers
-	a.mainStreamer = nil
-	a.auxStreamer = nil
-	a.mainVolume = nil
-	a.auxVolume = nil
+	oldMain := a.mainBus.device
+	oldAux := a.auxBus.device
+	a.mainBus.device = nil
+	a.auxBus.device = nil
 
 	// Capture Context to free
 	oldCtx := a.malCtx
@@ -1141,12 +1518,12 @@ func (a *App) ResetAudio() {
 	if oldAux != nil {
 		oldAux.Uninit()
 	}
+	a.mainBus.reset()
+	a.auxBus.reset()
 	if oldCtx != nil {
+		oldCtx.Uninit()
 		oldCtx.Free()
 	}
-
-	// 4. Re-init
-	a.initAudio()
 }
 
 func (a *App) calculateVolume(percent float64) float64 {
@@ -1165,39 +1542,23 @@ func (a *App) calculateVolume(percent float64) float64 {
 	return math.Log2(percent / 100.0)
 }
 
+// getConfigPath returns config.json in the user's config folder, or beside
+// the exe in portable mode
 func (a *App) getConfigPath() string {
-	configDir, err := os.UserConfigDir()
-	if err != nil {
-		return "daitoue.json" // fallback
-	}
-	dir := filepath.Join(configDir, "daitoue")
+	dir := getAppDirs().data
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		os.MkdirAll(dir, 0755)
 	}
 	return filepath.Join(dir, "config.json")
 }
 
-func (a *App) loadConfig() {
-	path := a.getConfigPath()
-	b, err := os.ReadFile(path)
-	if err == nil {
-		json.Unmarshal(b, &a.Config)
-	} else {
-		// Try legacy path
-		b, err := os.ReadFile("daitoue.json")
-		if err == nil {
-			// Migrate: old format was list of AudioItem
-			var list []*AudioItem
-			if json.Unmarshal(b, &list) == nil {
-				a.Config.AudioList = list
-				a.saveConfig() // Save to new location
-			}
-		}
+// getLibraryDir returns the folder for clips the app creates itself (replays, recordings)
+func (a *App) getLibraryDir() string {
+	a.mu.Lock()
+	dir := a.libraryRoot()
+	a.mu.Unlock()
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		os.MkdirAll(dir, 0755)
 	}
-}
-
-func (a *App) saveConfig() {
-	path := a.getConfigPath()
-	b, _ := json.MarshalIndent(a.Config, "", "  ") // Pretty print
-	os.WriteFile(path, b, 0644)
+	return dir
 }
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"io"
+	"math"
+	"sync/atomic"
+
+	"github.com/gen2brain/malgo"
+	"github.com/gopxl/beep/v2"
+	"github.com/gopxl/beep/v2/effects"
+)
+
+// scratchFrames is the size of each bus's preallocated mix buffer. Callbacks
+// larger than this are rendered in several chunks rather than allocating.
+const scratchFrames = 4096
+
+// voice is one clip playing on one bus
+type voice struct {
+	id       string
+	streamer beep.Streamer
+	source   *clipSource     // nil for live input
+	rate     beep.SampleRate // Clip frames per second as played (with any speed effect), for converting positions to time
+	duration float64         // Seconds, as played
+	closer   io.Closer       // Decoder of a streamed clip, closed once the voice is released
+
+	// Audio thread only, once the voice has been sent
+	fadeIn        int // Frames to ramp in over
+	fadeOut       int // Frames to ramp out over once stopped or replaced, 0 = not fading
+	inPos, outPos int
+}
+
+// close releases the voice's decoder, if it has one of its own. Not on the audio thread.
+func (v *voice) close() {
+	if v != nil && v.closer != nil {
+		v.closer.Close()
+	}
+}
+
+// render streams the voice into dst with its fades applied, zero-filling
+// whatever it doesn't cover, and reports whether the voice has finished
+func (v *voice) render(dst [][2]float64) (finished bool) {
+	n, ok := v.streamer.Stream(dst)
+	clear(dst[n:])
+
+	out := v.fadeOut
+	if v.inPos >= v.fadeIn && out == 0 {
+		return !ok || n < len(dst)
+	}
+	for i := range dst[:n] {
+		g := 1.0
+		if v.inPos < v.fadeIn {
+			g = math.Sin(math.Pi / 2 * float64(v.inPos) / float64(v.fadeIn))
+			v.inPos++
+		}
+		if out > 0 {
+			if v.outPos >= out {
+				clear(dst[i:])
+				return true
+			}
+			g *= math.Cos(math.Pi / 2 * float64(v.outPos) / float64(out))
+			v.outPos++
+		}
+		dst[i][0] *= g
+		dst[i][1] *= g
+	}
+	return !ok || n < len(dst) || (out > 0 && v.outPos >= out)
+}
+
+// position returns how far into the clip the voice is, in seconds
+func (v *voice) position() float64 {
+	if v.source == nil || v.rate == 0 {
+		return 0
+	}
+	return min(float64(v.source.frames.Load())/float64(v.rate), v.duration)
+}
+
+// frameAt converts seconds into the clip, as played, to a frame
+func (v *voice) frameAt(seconds float64) int64 {
+	return int64(seconds * float64(v.rate))
+}
+
+// clipSource is the seekable head of a voice's streamer graph. Seeks and
+// pauses arrive as commands; other threads only read the atomics back.
+type clipSource struct {
+	src    beep.StreamSeeker
+	frames atomic.Int64 // Current position
+	paused atomic.Bool
+}
+
+func newClipSource(src beep.StreamSeeker) *clipSource {
+	return &clipSource{src: src}
+}
+
+// seek moves to frame, clamped to the clip. Audio thread only.
+func (c *clipSource) seek(frame int64) {
+	frame = max(0, min(frame, int64(c.src.Len())))
+	if err := c.src.Seek(int(frame)); err == nil {
+		c.frames.Store(frame)
+	}
+}
+
+func (c *clipSource) Stream(samples [][2]float64) (n int, ok bool) {
+	if c.paused.Load() {
+		// Keep the voice alive; effects downstream ring out naturally
+		clear(samples)
+		return len(samples), true
+	}
+	n, ok = c.src.Stream(samples)
+	c.frames.Add(int64(n))
+	return n, ok
+}
+
+func (c *clipSource) Err() error {
+	return c.src.Err()
+}
+
+// bus is one playback output (main or aux).
+//
+// The audio thread never takes a lock: it owns the voices, which other
+// threads change only by sending commands (see engine.go), and scratch is
+// only ever touched from the callback.
+type bus struct {
+	cmds      *spscQueue[command]
+	events    *spscQueue[engineEvent]
+	playing   atomic.Pointer[voice]      // The current voice as of the last callback, for other threads
+	tap       atomic.Pointer[ringBuffer] // Receives the mixed output while recording
+	sink      atomic.Pointer[ringBuffer] // Also receives it, for checking output headless (see captureOutput)
+	period    atomic.Uint32              // Last callback size
+	dropped   atomic.Uint64              // Commands lost to a full queue
+	limiter   atomic.Pointer[limiter]
+	reduction atomic.Uint64 // Float64bits of the limiter's gain reduction in dB
+	clips     atomic.Uint64
+	scratch   [][2]float64
+	inScratch [][2]float64
+	xfScratch [][2]float64 // For the outgoing voice
+
+	// Output levels since the meter was last read. The audio thread adds up
+	// into acc and, whenever the meter has taken the last snapshot, moves
+	// it to levels and sets levelsReady; the meter reads levels only while
+	// levelsReady is set and clears it when done.
+	acc         meterLevels // Audio thread only
+	levels      meterLevels
+	levelsReady atomic.Bool
+
+	// Audio thread only (or while no device is running)
+	cur  *voice
+	out  *voice // Fading out under cur
+	next *voice // Starts when cur finishes
+	in   *voice // Live input (mic pass-through) mixed under the clip
+
+	// Guarded by App.audioMu
+	device *malgo.Device
+	format outputFormat
+}
+
+func newBus() *bus {
+	return &bus{
+		cmds:      newSPSCQueue[command](commandQueueSize),
+		events:    newSPSCQueue[engineEvent](eventQueueSize),
+		scratch:   make([][2]float64, scratchFrames),
+		inScratch: make([][2]float64, scratchFrames),
+		xfScratch: make([][2]float64, scratchFrames),
+	}
+}
+
+// captureOutput starts copying what the bus plays into a ring holding frames
+// frames, and returns it. With the null backend nothing can be heard, so this
+// is how a headless run (CI) checks what would have been. nil detaches.
+func (b *bus) captureOutput(frames int) *ringBuffer {
+	if frames <= 0 {
+		b.sink.Store(nil)
+		return nil
+	}
+	r := newRingBuffer(frames)
+	b.sink.Store(r)
+	return r
+}
+
+// playingID returns the ID of the clip on this bus, or ""
+func (b *bus) playingID() string {
+	if v := b.playing.Load(); v != nil {
+		return v.id
+	}
+	return ""
+}
+
+// onSamples is the malgo data callback. It must not allocate or block.
+func (b *bus) onSamples(pOutput []byte, framecount uint32, channels int) {
+	b.period.Store(framecount)
+	b.apply()
+
+	stride := channels * 4
+	tap, sink := b.tap.Load(), b.sink.Load()
+	lim := b.limiter.Load()
+	if b.cur == nil && b.out == nil && b.in == nil && (lim == nil || lim.idle()) {
+		clear(pOutput)
+		if tap != nil {
+			tap.writeSilence(int(framecount))
+		}
+		if sink != nil {
+			sink.writeSilence(int(framecount))
+		}
+		return
+	}
+
+	written := 0
+	var peak, sq float64
+	for written < int(framecount) {
+		chunk := min(int(framecount)-written, len(b.scratch))
+		mix := b.scratch[:chunk]
+
+		if b.cur != nil {
+			if b.cur.render(mix) {
+				// The queued clip (if any) starts with the next chunk
+				b.release(b.cur)
+				b.cur, b.next = b.next, nil
+			}
+		} else {
+			clear(mix)
+		}
+		if b.out != nil {
+			xf := b.xfScratch[:chunk]
+			if b.out.render(xf) {
+				b.release(b.out)
+				b.out = nil
+			}
+			for i, s := range xf {
+				mix[i][0] += s[0]
+				mix[i][1] += s[1]
+			}
+		}
+		if b.in != nil {
+			b.in.streamer.Stream(b.inScratch[:chunk])
+			for i, s := range b.inScratch[:chunk] {
+				mix[i][0] += s[0]
+				mix[i][1] += s[1]
+			}
+		}
+
+		// The limiter delays the signal, so keep going with silence until it has let the tail out
+		if lim != nil {
+			if overs := lim.process(mix); overs > 0 {
+				b.clips.Add(uint64(overs))
+			}
+		}
+		for _, s := range mix {
+			peak = max(peak, math.Abs(s[0]), math.Abs(s[1]))
+			sq += s[0]*s[0] + s[1]*s[1]
+		}
+
+		encodeFrames(pOutput[written*stride:], mix, channels)
+		if tap != nil {
+			tap.write(mix)
+		}
+		if sink != nil {
+			sink.write(mix)
+		}
+		written += chunk
+
+		if b.cur == nil && b.out == nil && b.in == nil && (lim == nil || lim.idle()) {
+			break // Nothing left to play
+		}
+	}
+	b.playing.Store(b.cur)
+
+	if lim != nil {
+		b.reduction.Store(math.Float64bits(lim.gainReduction()))
+	}
+	b.acc.peak = max(b.acc.peak, peak)
+	b.acc.sumSq += sq
+	b.acc.count += uint64(written) * 2
+	if !b.levelsReady.Load() {
+		b.levels, b.acc = b.acc, meterLevels{}
+		b.levelsReady.Store(true)
+	}
+
+	// Zero the rest
+	clear(pOutput[written*stride:])
+	if tap != nil {
+		tap.writeSilence(int(framecount) - written)
+	}
+	if sink != nil {
+		sink.writeSilence(int(framecount) - written)
+	}
+}
+
+// encodeFrames writes stereo samples as interleaved F32 with the device's channel count
+func encodeFrames(p []byte, samples [][2]float64, channels int) {
+	stride := channels * 4
+	for i, s := range samples {
+		frame := p[i*stride : (i+1)*stride]
+		if channels == 1 {
+			putSample(frame, (s[0]+s[1])/2)
+			continue
+		}
+		putSample(frame[0:], s[0]) // Left
+		putSample(frame[4:], s[1]) // Right
+		// Any further channels (center, surround...) stay silent
+		clear(frame[8:])
+	}
+}
+
+// liveVolume is an effects.Volume that follows the app's volume setting. The
+// UI only writes the atomic; the audio thread applies it at the next buffer.
+type liveVolume struct {
+	effects.Volume
+	app *App
+}
+
+func (v *liveVolume) Stream(samples [][2]float64) (n int, ok bool) {
+	percent := math.Float64frombits(v.app.volume.Load())
+	v.Volume.Volume = v.app.calculateVolume(percent)
+	v.Volume.Silent = percent == 0
+	return v.Volume.Stream(samples)
+}
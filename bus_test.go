@@ -0,0 +1,75 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gopxl/beep/v2"
+	"github.com/gopxl/beep/v2/effects"
+)
+
+// sineClip is an endless stereo sine that can be seeked, standing in for a decoded clip
+type sineClip struct {
+	pos  int
+	rate beep.SampleRate
+}
+
+func (s *sineClip) Stream(samples [][2]float64) (int, bool) {
+	for i := range samples {
+		v := 0.9 * math.Sin(2*math.Pi*440*float64(s.pos)/float64(s.rate))
+		samples[i] = [2]float64{v, v}
+		s.pos++
+	}
+	return len(samples), true
+}
+
+func (s *sineClip) Err() error       { return nil }
+func (s *sineClip) Len() int         { return math.MaxInt32 }
+func (s *sineClip) Position() int    { return s.pos }
+func (s *sineClip) Seek(p int) error { s.pos = p; return nil }
+
+// newBusyBus returns a bus set up the way a recording session has it: a clip
+// playing through the app's volume, the limiter and a recorder tap
+func newBusyBus() *bus {
+	const rate = beep.SampleRate(48000)
+	b := newBus()
+	b.limiter.Store(newLimiter(rate, defaultLimiterCeiling))
+	b.tap.Store(newRingBuffer(int(rate)))
+	src := newClipSource(&sineClip{rate: rate})
+	b.cur = &voice{
+		id:       "sine",
+		streamer: &liveVolume{Volume: effects.Volume{Streamer: src, Base: 2}, app: NewApp()},
+		source:   src,
+		rate:     rate,
+		duration: math.MaxInt32 / float64(rate),
+	}
+	return b
+}
+
+func TestOnSamplesDoesNotAllocate(t *testing.T) {
+	b := newBusyBus()
+	const frames, channels = 480, 2
+	out := make([]byte, frames*channels*4)
+	tap := b.tap.Load()
+	allocs := testing.AllocsPerRun(200, func() {
+		b.onSamples(out, frames, channels)
+		tap.discard(tap.buffered())
+	})
+	if allocs != 0 {
+		t.Errorf("onSamples allocates %v times per callback, want 0", allocs)
+	}
+}
+
+func BenchmarkOnSamples(b *testing.B) {
+	bus := newBusyBus()
+	const frames, channels = 480, 2
+	out := make([]byte, frames*channels*4)
+	tap := bus.tap.Load()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(out)))
+	b.ResetTimer()
+	for range b.N {
+		bus.onSamples(out, frames, channels)
+		tap.discard(tap.buffered())
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/gen2brain/malgo"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// captureChannels is what every capture device is opened with; miniaudio
+// up/down-mixes from the device's native layout.
+const captureChannels = 2
+
+// Reason codes for captures that couldn't be saved as a clip
+const (
+	reasonNotCapturing = "not_capturing" // Nothing was recording
+	reasonNoAudio      = "no_audio"      // Nothing, or only silence, was captured
+	reasonWriteFailed  = "write_failed"
+)
+
+// captureFailed is the import entry of a capture that didn't get as far as a file
+func captureFailed(reason, message string) ImportEntry {
+	return ImportEntry{Status: importFailed, Reason: reason, Message: message}
+}
+
+// saveCapture writes captured samples to a new WAV called name in the
+// library and imports it
+func (a *App) saveCapture(name string, samples []float32, rate int) ImportEntry {
+	path := filepath.Join(a.getLibraryDir(), name)
+	if err := writeClip(path, samples, rate); err != nil {
+		os.Remove(path)
+		return ImportEntry{Name: name, Path: path, Status: importFailed, Reason: reasonWriteFailed, Message: err.Error()}
+	}
+
+	item, entry := a.importAudioFile(path)
+	if item == nil {
+		os.Remove(path)
+		return entry
+	}
+
+	a.mu.Lock()
+	a.saveConfig()
+	a.mu.Unlock()
+
+	runtime.EventsEmit(a.ctx, "audios-changed")
+	return entry
+}
+
+// captureCeilingSeconds bounds a capture with the import limits turned off,
+// as captures are held in memory
+const captureCeilingSeconds = 600
+
+// maxCaptureSeconds is how long a capture may run under these limits, going
+// by length alone
+func (s ImportSettings) maxCaptureSeconds() float64 {
+	if s.MaxSeconds > 0 {
+		return min(s.MaxSeconds, captureCeilingSeconds)
+	}
+	return captureCeilingSeconds
+}
+
+// maxCaptureFrames is the longest a captured clip can be and still pass these
+// limits once saved as a 16-bit stereo WAV
+func (s ImportSettings) maxCaptureFrames(rate int) int {
+	frames := int(s.maxCaptureSeconds() * float64(rate))
+	if s.MaxSizeMB > 0 {
+		frames = min(frames, (int(s.MaxSizeMB*1024*1024)-wavHeaderSize)/(captureChannels*2))
+	}
+	return max(frames, 0)
+}
+
+// openCapture initialises (but doesn't start) a capture device. kind is
+// malgo.Capture for a microphone or malgo.Loopback to record what a playback
+// device is playing; idStr selects the device ("" or "default" for the system
+// default) and sampleRate the rate to convert to (0 for the device's own).
+// Once started, onData receives interleaved stereo F32 on the audio thread.
+func (a *App) openCapture(kind malgo.DeviceType, idStr string, sampleRate uint32, onData func(pInput []byte, framecount uint32)) (*malgo.Device, int, error) {
+	deviceConfig := malgo.DefaultDeviceConfig(kind)
+	deviceConfig.Capture.Format = malgo.FormatF32
+	deviceConfig.Capture.Channels = captureChannels
+	deviceConfig.SampleRate = sampleRate
+	deviceConfig.Alsa.NoMMap = 1
+
+	// Loopback devices are addressed by their playback device ID
+	enumKind := malgo.Capture
+	if kind == malgo.Loopback {
+		enumKind = malgo.Playback
+	}
+	if isDeviceSelection(idStr) {
+		infos, err := a.malCtx.Devices(enumKind)
+		if err != nil {
+			return nil, 0, err
+		}
+		if info := matchDevice(infos, idStr, nil, ""); info != nil {
+			deviceConfig.Capture.DeviceID = info.ID.Pointer()
+		}
+	}
+
+	device, err := malgo.InitDevice(a.malCtx.Context, deviceConfig, malgo.DeviceCallbacks{
+		Data: func(pOutput, pInput []byte, framecount uint32) {
+			onData(pInput, framecount)
+		},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return device, int(device.SampleRate()), nil
+}
+
+// GetCaptureDevices lists microphones and other input devices
+func (a *App) GetCaptureDevices() []AudioDevice {
+	if a.malCtx == nil {
+		return []AudioDevice{}
+	}
+
+	infos, err := a.malCtx.Devices(malgo.Capture)
+	if err != nil {
+		return []AudioDevice{}
+	}
+
+	var devices []AudioDevice
+	for i := range infos {
+		devices = append(devices, AudioDevice{
+			ID:        infos[i].ID.String(),
+			Name:      infos[i].Name(),
+			IsDefault: infos[i].IsDefault != 0,
+		})
+	}
+	return devices
+}
+
+// getSample reads one F32 little-endian sample
+func getSample(p []byte) float32 {
+	return math.Float32frombits(binary.LittleEndian.Uint32(p))
+}
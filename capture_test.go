@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestCaptureLimitsFollowImportSettings(t *testing.T) {
+	const rate = 48000
+	bytesPerFrame := captureChannels * 2
+	for _, tc := range []struct {
+		name   string
+		limits ImportSettings
+		frames int
+	}{
+		{"length", ImportSettings{MaxSeconds: 20}, 20 * rate},
+		{"size", ImportSettings{MaxSizeMB: 1, MaxSeconds: 100}, (1024*1024 - wavHeaderSize) / bytesPerFrame},
+		{"none", ImportSettings{}, captureCeilingSeconds * rate},
+		{"over the ceiling", ImportSettings{MaxSeconds: 3600}, captureCeilingSeconds * rate},
+	} {
+		if got := tc.limits.maxCaptureFrames(rate); got != tc.frames {
+			t.Errorf("%s: maxCaptureFrames = %d, want %d", tc.name, got, tc.frames)
+		}
+	}
+
+	limits := ImportSettings{MaxSeconds: 20}
+	if got := (ReplaySettings{Seconds: 60}).normalize(limits).Seconds; got != 20 {
+		t.Errorf("replay of 60s under a 20s limit normalized to %ds", got)
+	}
+	if got := newReplayBuffer(rate, 60, limits); got.keep != 20*rate*captureChannels {
+		t.Errorf("replay buffer keeps %d samples, want 20s worth", got.keep)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/gen2brain/malgo"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ClipRecordingLevel is emitted as "clip-recording-level" while recording a clip
+type ClipRecordingLevel struct {
+	Peak    float64 `json:"peak"` // 0-1, over the last interval
+	RMS     float64 `json:"rms"`
+	Seconds float64 `json:"seconds"`
+	Full    bool    `json:"full"` // Hit the import limit; nothing more is kept
+}
+
+const (
+	clipSilenceThreshold = 0.02 // About -34 dBFS
+	clipSilencePad       = 0.1  // Seconds kept either side of the trimmed sound
+)
+
+// clipRecorder captures a microphone into memory until stopped
+type clipRecorder struct {
+	device    *malgo.Device
+	ring      *ringBuffer
+	rate      int
+	maxFrames int
+	samples   []float32 // Interleaved stereo, only touched by run until done is closed
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func (r *clipRecorder) run(a *App) {
+	buf := make([]float32, 8192)
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	defer close(r.done)
+
+	for {
+		select {
+		case <-r.stop:
+			r.drain(buf)
+			return
+		case <-ticker.C:
+			peak, rms := r.drain(buf)
+			runtime.EventsEmit(a.ctx, "clip-recording-level", ClipRecordingLevel{
+				Peak:    peak,
+				RMS:     rms,
+				Seconds: float64(len(r.samples)/captureChannels) / float64(r.rate),
+				Full:    len(r.samples) >= r.maxFrames*captureChannels,
+			})
+		}
+	}
+}
+
+// drain moves everything captured so far into samples and returns its levels
+func (r *clipRecorder) drain(buf []float32) (peak, rms float64) {
+	var sum float64
+	var count int
+	for {
+		n := r.ring.read(buf)
+		if n == 0 {
+			break
+		}
+		for _, x := range buf[:n] {
+			v := math.Abs(float64(x))
+			peak = max(peak, v)
+			sum += v * v
+		}
+		count += n
+
+		room := r.maxFrames*captureChannels - len(r.samples)
+		r.samples = append(r.samples, buf[:min(n, room)]...)
+	}
+	if count > 0 {
+		rms = math.Sqrt(sum / float64(count))
+	}
+	return peak, rms
+}
+
+// trimSilence cuts quiet frames off both ends of interleaved stereo samples,
+// keeping pad frames either side. Returns nil if nothing rises above threshold.
+func trimSilence(samples []float32, threshold float32, pad int) []float32 {
+	loud := func(i int) bool {
+		return abs32(samples[i*2]) > threshold || abs32(samples[i*2+1]) > threshold
+	}
+
+	frames := len(samples) / 2
+	first := 0
+	for first < frames && !loud(first) {
+		first++
+	}
+	if first == frames {
+		return nil
+	}
+	last := frames - 1
+	for last > first && !loud(last) {
+		last--
+	}
+
+	first = max(first-pad, 0)
+	last = min(last+pad, frames-1)
+	return samples[first*2 : (last+1)*2]
+}
+
+func abs32(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// StartClipRecording records from a microphone ("" = system default) until StopClipRecording
+func (a *App) StartClipRecording(deviceID string) string {
+	if a.malCtx == nil {
+		return "Error: 音频未初始化"
+	}
+
+	a.mu.Lock()
+	limits := a.Config.Import
+	a.mu.Unlock()
+
+	a.audioMu.Lock()
+	defer a.audioMu.Unlock()
+	if a.clipRecorder != nil {
+		return "Error: 正在录音"
+	}
+
+	var ring *ringBuffer
+	device, rate, err := a.openCapture(malgo.Capture, deviceID, 0, func(pInput []byte, framecount uint32) {
+		ring.writeF32(pInput, int(framecount))
+	})
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to open clip recording device: %v", err)
+		return "Error: 无法打开录音设备"
+	}
+	ring = newRingBuffer(rate * recorderBufferSeconds)
+
+	r := &clipRecorder{
+		device:    device,
+		ring:      ring,
+		rate:      rate,
+		maxFrames: limits.maxCaptureFrames(rate),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if err := device.Start(); err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to start clip recording device: %v", err)
+		device.Uninit()
+		return "Error: 无法打开录音设备"
+	}
+	go r.run(a)
+	a.clipRecorder = r
+	return "OK"
+}
+
+// takeClipRecorder stops capturing and returns the finished recorder, or nil
+func (a *App) takeClipRecorder() *clipRecorder {
+	a.audioMu.Lock()
+	r := a.clipRecorder
+	a.clipRecorder = nil
+	a.audioMu.Unlock()
+
+	if r == nil {
+		return nil
+	}
+	r.device.Uninit()
+	close(r.stop)
+	<-r.done
+	return r
+}
+
+// StopClipRecording trims the recording, saves it to the library and adds it to the list
+func (a *App) StopClipRecording() ImportEntry {
+	r := a.takeClipRecorder()
+	if r == nil {
+		return captureFailed(reasonNotCapturing, "未在录音")
+	}
+
+	samples := trimSilence(r.samples, clipSilenceThreshold, int(clipSilencePad*float64(r.rate)))
+	if samples == nil {
+		return captureFailed(reasonNoAudio, "未检测到声音")
+	}
+	return a.saveCapture("录音_"+time.Now().Format("20060102_150405")+".wav", samples, r.rate)
+}
+
+// CancelClipRecording stops recording and throws the audio away
+func (a *App) CancelClipRecording() {
+	a.takeClipRecorder()
+}
+
+// writeClip saves interleaved stereo samples as a WAV
+func writeClip(path string, samples []float32, rate int) error {
+	if len(samples) == 0 {
+		return errors.New("没有音频数据")
+	}
+	w, err := newAudioWriter(path, "wav", rate, captureChannels)
+	if err != nil {
+		return err
+	}
+	err = w.WriteSamples(samples)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
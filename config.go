@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// configSchemaVersion is the layout saveConfig writes. Adding fields whose
+// zero value is a fine default needs no new version; anything that moves or
+// reinterprets existing data bumps it and appends to configMigrations.
+const configSchemaVersion = 1
+
+// configBackups is how many earlier configs are kept, as config.json.1
+// (newest) to config.json.3. One is taken the first time each session saves.
+const configBackups = 3
+
+const (
+	configSaveDelay    = 500 * time.Millisecond // Quiet time before a change is written
+	configSaveMaxDelay = 3 * time.Second        // Longest a change waits while others keep coming
+)
+
+// legacyConfigName is the file the first versions kept the clip list in, next to the exe
+const legacyConfigName = "daitoue.json"
+
+// ConfigStatus reports problems with the config file to the frontend
+type ConfigStatus struct {
+	LoadError     string `json:"load_error"`     // Why config.json couldn't be read at startup
+	RecoveredFrom string `json:"recovered_from"` // Backup loaded instead, "" if none could be
+	NewerVersion  int    `json:"newer_version"`  // Schema version of a config.json from a newer release, which is left alone
+	SaveError     string `json:"save_error"`     // From the last save, "" once one succeeds
+}
+
+// configMigration rewrites a config document from one schema version to the next
+type configMigration func(doc json.RawMessage) (json.RawMessage, error)
+
+// configMigrations[i] upgrades version i to i+1
+var configMigrations = []configMigration{
+	// 0: the legacy daitoue.json, a bare list of clips
+	func(doc json.RawMessage) (json.RawMessage, error) {
+		return json.Marshal(map[string]json.RawMessage{"audio_list": doc})
+	},
+}
+
+// configVersion works out the schema version of a document. Configs saved
+// before the version was recorded are version 1, or 0 for the legacy list.
+func configVersion(doc json.RawMessage) (int, error) {
+	doc = bytes.TrimSpace(doc)
+	if len(doc) > 0 && doc[0] == '[' {
+		return 0, nil
+	}
+	var header struct {
+		SchemaVersion *int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(doc, &header); err != nil {
+		return 0, err
+	}
+	if header.SchemaVersion == nil {
+		return 1, nil
+	}
+	return *header.SchemaVersion, nil
+}
+
+// migrateConfig brings a document up to configSchemaVersion
+func migrateConfig(doc json.RawMessage) (json.RawMessage, error) {
+	version, err := configVersion(doc)
+	if err != nil {
+		return nil, err
+	}
+	if version > configSchemaVersion {
+		// Written by a newer release; read what we understand. The version
+		// decoded along with it keeps the file from being saved over.
+		return doc, nil
+	}
+	for v := version; v < configSchemaVersion; v++ {
+		if doc, err = configMigrations[v](doc); err != nil {
+			return nil, fmt.Errorf("migrating from version %d: %w", v, err)
+		}
+	}
+	return doc, nil
+}
+
+// applyConfig migrates and decodes a config document over the current
+// (default) settings, leaving them untouched if it can't be used
+func (a *App) applyConfig(doc []byte) error {
+	migrated, err := migrateConfig(doc)
+	if err != nil {
+		return err
+	}
+	var probe Config
+	if err := json.Unmarshal(migrated, &probe); err != nil {
+		return err
+	}
+	return json.Unmarshal(migrated, &a.Config)
+}
+
+func (a *App) loadConfig() {
+	if err := a.readConfig(a.getConfigPath()); err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to load config: %v", err)
+	}
+	a.Config.Output = a.Config.Output.normalize()
+	root := a.libraryRoot()
+	for _, item := range a.Config.AudioList {
+		item.Path = resolvePath(item.Path, root)
+	}
+	for i, dir := range a.Config.WatchFolders {
+		a.Config.WatchFolders[i] = resolvePath(dir, root)
+	}
+	a.Config.Recording.Folder = resolvePath(a.Config.Recording.Folder, root)
+}
+
+// readConfig loads the config at path over the defaults. A file that can't
+// be used is set aside as .broken and the newest usable backup is loaded
+// instead; what went wrong is returned for the log.
+func (a *App) readConfig(path string) error {
+	b, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err = a.applyConfig(b); err == nil {
+			break
+		}
+		// Keep the broken file for inspection; the next save would overwrite it
+		os.Rename(path, path+".broken")
+		fallthrough
+	case !errors.Is(err, os.ErrNotExist):
+		a.configStatus.LoadError = err.Error()
+		err = errors.Join(err, a.recoverConfig(path))
+	default:
+		// First run, or upgrading from the legacy list
+		err = nil
+		if b, err := os.ReadFile(filepath.Join(getAppDirs().exe, legacyConfigName)); err == nil && a.applyConfig(b) == nil {
+			a.saveConfig() // Save to new location
+		}
+	}
+
+	if v := a.Config.SchemaVersion; v > configSchemaVersion {
+		a.configStatus.NewerVersion = v
+		a.configStatus.LoadError = fmt.Sprintf("%s 由较新版本保存 (格式版本 %d)，本版本的更改将保存到 %s",
+			filepath.Base(path), v, filepath.Base(a.configSavePath(path)))
+	}
+	return err
+}
+
+// recoverConfig loads the newest usable backup, if there is one, and
+// returns why the others couldn't be used
+func (a *App) recoverConfig(path string) error {
+	var errs []error
+	for i := 1; i <= configBackups; i++ {
+		backup := fmt.Sprintf("%s.%d", path, i)
+		b, err := os.ReadFile(backup)
+		if err != nil {
+			continue
+		}
+		if err := a.applyConfig(b); err != nil {
+			errs = append(errs, fmt.Errorf("backup %s is unusable too: %w", backup, err))
+			continue
+		}
+		a.configStatus.RecoveredFrom = filepath.Base(backup)
+		a.saveConfig()
+		break
+	}
+	return errors.Join(errs...)
+}
+
+// configSavePath is where the config at path is saved. A config written by
+// a newer release is never saved over, as that would drop whatever this
+// build doesn't know about: changes go to a file of their own beside it.
+// Caller holds a.mu.
+func (a *App) configSavePath(path string) string {
+	if a.Config.SchemaVersion > configSchemaVersion {
+		return filepath.Join(filepath.Dir(path), fmt.Sprintf("config.v%d.json", configSchemaVersion))
+	}
+	return path
+}
+
+// saveConfig marks the config as changed; the writer goroutine saves it
+// once changes have settled, so callers never wait on the disk. Caller holds a.mu.
+func (a *App) saveConfig() {
+	a.configDirty = true
+	select {
+	case a.configChanged <- struct{}{}:
+	default:
+	}
+}
+
+// runConfigWriter saves the config in the background: after a change it
+// waits for configSaveDelay without further changes (but no longer than
+// configSaveMaxDelay) and writes it out, and it writes once more on the way out
+func (a *App) runConfigWriter(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			a.flushConfig()
+			return
+		case <-a.configChanged:
+		}
+
+		timer := time.NewTimer(configSaveDelay)
+		deadline := time.NewTimer(configSaveMaxDelay)
+	settle:
+		for {
+			select {
+			case <-a.configChanged:
+				timer.Reset(configSaveDelay)
+			case <-timer.C:
+				break settle
+			case <-deadline.C:
+				break settle
+			case <-ctx.Done():
+				break settle
+			}
+		}
+		timer.Stop()
+		deadline.Stop()
+		a.flushConfig()
+	}
+}
+
+// flushConfig writes the config if it has changed since it was last written.
+// The config is snapshotted under a.mu and written outside it.
+func (a *App) flushConfig() {
+	a.saveMu.Lock()
+	defer a.saveMu.Unlock()
+
+	a.mu.Lock()
+	if !a.configDirty {
+		a.mu.Unlock()
+		return
+	}
+	a.configDirty = false
+	cfg := a.configForDisk()
+	cfg.SchemaVersion = configSchemaVersion
+	b, err := json.MarshalIndent(cfg, "", "  ") // Pretty print
+	backup := !a.configBackedUp
+	a.configBackedUp = true
+	path := a.configSavePath(a.getConfigPath())
+	a.mu.Unlock()
+
+	if err == nil {
+		if backup {
+			if err := rotateBackups(path, configBackups); err != nil {
+				runtime.LogErrorf(a.ctx, "Failed to back up config: %v", err)
+			}
+		}
+		err = writeFileAtomic(path, b, 0644)
+	}
+
+	a.mu.Lock()
+	first := a.configStatus.SaveError == ""
+	if err != nil {
+		a.configDirty = true // Try again with the next change, or at shutdown
+		a.configStatus.SaveError = err.Error()
+	} else {
+		a.configStatus.SaveError = ""
+	}
+	a.mu.Unlock()
+
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to save config: %v", err)
+		if first {
+			runtime.EventsEmit(a.ctx, "config-error", "保存配置失败: "+err.Error())
+		}
+	}
+}
+
+// configForDisk is the config as it is written out, with clip, watch
+// folder and recording folder paths in their stored form. Caller holds a.mu.
+func (a *App) configForDisk() Config {
+	cfg := a.Config
+	root := a.libraryRoot()
+	cfg.AudioList = make([]*AudioItem, len(a.Config.AudioList))
+	for i, item := range a.Config.AudioList {
+		stored := *item
+		stored.Path = storePath(item.Path, root)
+		cfg.AudioList[i] = &stored
+	}
+	cfg.WatchFolders = make([]string, len(a.Config.WatchFolders))
+	for i, dir := range a.Config.WatchFolders {
+		cfg.WatchFolders[i] = storePath(dir, root)
+	}
+	cfg.Recording.Folder = storePath(a.Config.Recording.Folder, root)
+	return cfg
+}
+
+// writeFileAtomic replaces path with data so that a crash leaves either the
+// old file or the new one, never a mix: the data goes to a temporary file in
+// the same folder, is flushed to disk, and is then renamed over path.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// rotateBackups shifts path.1 ... path.(n-1) up by one and copies path to path.1
+func rotateBackups(path string, n int) error {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil // Nothing saved yet
+	}
+	if err != nil {
+		return err
+	}
+	for i := n - 1; i >= 1; i-- {
+		from := fmt.Sprintf("%s.%d", path, i)
+		if _, err := os.Stat(from); err == nil {
+			if err := os.Rename(from, fmt.Sprintf("%s.%d", path, i+1)); err != nil {
+				return err
+			}
+		}
+	}
+	return writeFileAtomic(path+".1", b, 0644)
+}
+
+// GetConfigStatus reports whether the config loaded cleanly and the last save worked
+func (a *App) GetConfigStatus() ConfigStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.configStatus
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withAppDirs points the app's folders at dirs for the rest of the test
+func withAppDirs(t *testing.T, dirs appDirs) {
+	t.Helper()
+	saved := getAppDirs
+	getAppDirs = func() appDirs { return dirs }
+	t.Cleanup(func() { getAppDirs = saved })
+}
+
+// writeFile writes content to path, failing the test if it can't
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readFile returns what is in path, failing the test if it can't be read
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestConfigVersion(t *testing.T) {
+	for _, tc := range []struct {
+		name, doc string
+		version   int
+	}{
+		{"legacy list", `[{"id":"1","path":"a.wav"}]`, 0},
+		{"legacy list with space", " \n[]", 0},
+		{"no version", `{"audio_list":[]}`, 1},
+		{"current", `{"schema_version":1,"audio_list":[]}`, 1},
+		{"newer", `{"schema_version":7}`, 7},
+	} {
+		if got, err := configVersion(json.RawMessage(tc.doc)); err != nil || got != tc.version {
+			t.Errorf("%s: configVersion = %d, %v, want %d", tc.name, got, err, tc.version)
+		}
+	}
+	if _, err := configVersion(json.RawMessage(`{"schema_version":`)); err == nil {
+		t.Error("truncated document has a version")
+	}
+}
+
+func TestMigrateConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name, doc string
+		clips     int
+		version   int // Decoded schema_version
+	}{
+		{"legacy list", `[{"id":"1","path":"a.wav"},{"id":"2","path":"b.wav"}]`, 2, 0},
+		{"no version", `{"audio_list":[{"id":"1"}]}`, 1, 0},
+		{"current", `{"schema_version":1,"audio_list":[{"id":"1"}]}`, 1, 1},
+		{"newer", `{"schema_version":2,"audio_list":[{"id":"1"}],"added_later":true}`, 1, 2},
+	} {
+		doc, err := migrateConfig(json.RawMessage(tc.doc))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(doc, &fields); err != nil || fields["audio_list"] == nil {
+			t.Errorf("%s: migrated to %s, want an object with audio_list", tc.name, doc)
+			continue
+		}
+		var cfg Config
+		json.Unmarshal(doc, &cfg)
+		if len(cfg.AudioList) != tc.clips || cfg.SchemaVersion != tc.version {
+			t.Errorf("%s: %d clips at version %d, want %d at %d", tc.name, len(cfg.AudioList), cfg.SchemaVersion, tc.clips, tc.version)
+		}
+		if tc.version > configSchemaVersion && !bytes.Equal(doc, []byte(tc.doc)) {
+			t.Errorf("%s: newer document rewritten to %s", tc.name, doc)
+		}
+	}
+}
+
+func TestNewerConfigIsNotOverwritten(t *testing.T) {
+	dir := t.TempDir()
+	withAppDirs(t, appDirs{exe: dir, data: dir})
+	path := filepath.Join(dir, "config.json")
+	newer := `{"schema_version":2,"audio_list":[{"id":"1","path":"a.wav"}],"added_later":true}`
+	writeFile(t, path, newer)
+
+	a := NewApp()
+	if err := a.readConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	if a.configStatus.NewerVersion != 2 || a.configStatus.LoadError == "" {
+		t.Errorf("status = %+v, want the newer version reported", a.configStatus)
+	}
+
+	a.mu.Lock()
+	a.Config.Volume = 50
+	a.saveConfig()
+	a.mu.Unlock()
+	a.flushConfig()
+
+	if got := readFile(t, path); got != newer {
+		t.Errorf("config.json from a newer release overwritten with %s", got)
+	}
+	var saved Config
+	if err := json.Unmarshal([]byte(readFile(t, filepath.Join(dir, "config.v1.json"))), &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.SchemaVersion != configSchemaVersion || saved.Volume != 50 {
+		t.Errorf("changes saved at version %d with volume %v", saved.SchemaVersion, saved.Volume)
+	}
+	if a.Config.SchemaVersion != 2 {
+		t.Errorf("schema version lowered to %d by saving", a.Config.SchemaVersion)
+	}
+}
+
+func TestLegacyConfigIsMigrated(t *testing.T) {
+	dir := t.TempDir()
+	withAppDirs(t, appDirs{exe: dir, data: filepath.Join(dir, "data")})
+	writeFile(t, filepath.Join(dir, legacyConfigName), `[{"id":"1","path":"a.wav"}]`)
+
+	a := NewApp()
+	if err := a.readConfig(filepath.Join(dir, "data", "config.json")); err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Config.AudioList) != 1 || a.Config.AudioList[0].ID != "1" {
+		t.Errorf("clips = %v, want the legacy list's", a.Config.AudioList)
+	}
+	if !a.configDirty {
+		t.Error("migrated config not saved to the new location")
+	}
+}
+
+func TestRotateBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := rotateBackups(path, configBackups); err != nil {
+		t.Errorf("rotating before anything was saved: %v", err)
+	}
+	for i := 1; i <= 5; i++ {
+		if err := writeFileAtomic(path, []byte(fmt.Sprint(i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := rotateBackups(path, configBackups); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, want := range []string{"5", "4", "3"} {
+		if got := readFile(t, fmt.Sprintf("%s.%d", path, i+1)); got != want {
+			t.Errorf("config.json.%d = %q, want %q", i+1, got, want)
+		}
+	}
+	files, _ := filepath.Glob(path + "*")
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+	}
+	if want := "config.json config.json.1 config.json.2 config.json.3"; strings.Join(names, " ") != want {
+		t.Errorf("files = %v, want %s", names, want)
+	}
+}
+
+func TestCorruptConfigRecovers(t *testing.T) {
+	const good = `{"schema_version":1,"audio_list":[{"id":"%d"}]}`
+	for _, tc := range []struct {
+		name      string
+		backups   []string // config.json.1 onwards, "" for none
+		recovered string
+		clip      string
+	}{
+		{"from the newest backup", []string{fmt.Sprintf(good, 1), fmt.Sprintf(good, 2)}, "config.json.1", "1"},
+		{"past a broken backup", []string{"{", fmt.Sprintf(good, 2)}, "config.json.2", "2"},
+		{"past a missing backup", []string{"", "", fmt.Sprintf(good, 3)}, "config.json.3", "3"},
+		{"to the defaults", nil, "", ""},
+	} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "config.json")
+		writeFile(t, path, `{"audio_list": [`)
+		for i, b := range tc.backups {
+			if b != "" {
+				writeFile(t, fmt.Sprintf("%s.%d", path, i+1), b)
+			}
+		}
+
+		a := NewApp()
+		a.readConfig(path)
+		if a.configStatus.LoadError == "" || a.configStatus.RecoveredFrom != tc.recovered {
+			t.Errorf("%s: status = %+v, want recovery from %q", tc.name, a.configStatus, tc.recovered)
+		}
+		if got := readFile(t, path+".broken"); got != `{"audio_list": [` {
+			t.Errorf("%s: config.json.broken = %q", tc.name, got)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s: broken config.json still in place", tc.name)
+		}
+		if tc.clip == "" {
+			if len(a.Config.AudioList) != 0 {
+				t.Errorf("%s: clips = %v, want the defaults", tc.name, a.Config.AudioList)
+			}
+		} else if len(a.Config.AudioList) != 1 || a.Config.AudioList[0].ID != tc.clip {
+			t.Errorf("%s: clips = %v, want clip %s", tc.name, a.Config.AudioList, tc.clip)
+		}
+		if tc.recovered != "" && !a.configDirty {
+			t.Errorf("%s: recovered config not saved", tc.name)
+		}
+	}
+}
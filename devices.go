@@ -0,0 +1,243 @@
+package main
+
+import (
+	goruntime "runtime"
+	"strings"
+	"unicode"
+
+	"github.com/gen2brain/malgo"
+)
+
+// DeviceDescriptor remembers enough about an output device to find it again
+// after its backend ID changes (driver updates, re-plugging, reboots).
+type DeviceDescriptor struct {
+	ID       string `json:"id"`       // malgo.DeviceID.String()
+	Name     string `json:"name"`     // Friendly name as reported by the backend
+	Channels uint32 `json:"channels"` // Native channel count, 0 if unknown
+	Backend  string `json:"backend"`  // e.g. "wasapi"
+}
+
+// AudioFormat is one native data format supported by a device
+type AudioFormat struct {
+	Format     string `json:"format"` // "u8", "s16", "s24", "s32" or "f32"
+	Channels   uint32 `json:"channels"`
+	SampleRate uint32 `json:"sample_rate"`
+	Exclusive  bool   `json:"exclusive"` // Only available in exclusive mode
+}
+
+// maDataFormatFlagExclusiveMode mirrors MA_DATA_FORMAT_FLAG_EXCLUSIVE_MODE
+const maDataFormatFlagExclusiveMode = 1 << 1
+
+// fuzzyNameThreshold is the minimum token overlap for a fuzzy name match
+const fuzzyNameThreshold = 0.5
+
+var backendNames = map[malgo.Backend]string{
+	malgo.BackendWasapi:     "wasapi",
+	malgo.BackendDsound:     "dsound",
+	malgo.BackendWinmm:      "winmm",
+	malgo.BackendCoreaudio:  "coreaudio",
+	malgo.BackendSndio:      "sndio",
+	malgo.BackendAudio4:     "audio4",
+	malgo.BackendOss:        "oss",
+	malgo.BackendPulseaudio: "pulseaudio",
+	malgo.BackendAlsa:       "alsa",
+	malgo.BackendJack:       "jack",
+	malgo.BackendAaudio:     "aaudio",
+	malgo.BackendOpensl:     "opensl",
+	malgo.BackendWebaudio:   "webaudio",
+	backendNull:             "null",
+}
+
+// backendNull is miniaudio's null backend, which runs a device on a timer and
+// discards its output. malgo.BackendNull is one short: in the bundled
+// miniaudio that value is ma_backend_custom, which fails with "No backend".
+const backendNull = malgo.BackendNull + 1
+
+var formatNames = map[malgo.FormatType]string{
+	malgo.FormatU8:  "u8",
+	malgo.FormatS16: "s16",
+	malgo.FormatS24: "s24",
+	malgo.FormatS32: "s32",
+	malgo.FormatF32: "f32",
+}
+
+// platformBackends returns the backends miniaudio would try by default, in order.
+// We try them one at a time so we know which one is actually in use.
+func platformBackends() []malgo.Backend {
+	switch goruntime.GOOS {
+	case "windows":
+		return []malgo.Backend{malgo.BackendWasapi, malgo.BackendDsound, malgo.BackendWinmm}
+	case "darwin":
+		return []malgo.Backend{malgo.BackendCoreaudio}
+	case "linux":
+		return []malgo.Backend{malgo.BackendPulseaudio, malgo.BackendAlsa, malgo.BackendJack}
+	default:
+		return []malgo.Backend{malgo.BackendSndio, malgo.BackendAudio4, malgo.BackendOss}
+	}
+}
+
+// selectableBackends is what the user may choose from on this platform
+func selectableBackends() []malgo.Backend {
+	return append(platformBackends(), backendNull)
+}
+
+// probeBackend reports whether a context can be created with the given backend
+func probeBackend(b malgo.Backend) bool {
+	ctx, err := malgo.InitContext([]malgo.Backend{b}, malgo.ContextConfig{}, nil)
+	if err != nil {
+		return false
+	}
+	ctx.Uninit()
+	ctx.Free()
+	return true
+}
+
+func parseBackend(name string) (malgo.Backend, bool) {
+	for b, n := range backendNames {
+		if n == name {
+			return b, true
+		}
+	}
+	return 0, false
+}
+
+func backendName(b malgo.Backend) string {
+	if name, ok := backendNames[b]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// isDeviceSelection reports whether a configured device value names a real device
+// rather than one of the "default"/"none" placeholders.
+func isDeviceSelection(idStr string) bool {
+	return idStr != "" && idStr != "default" && idStr != "none"
+}
+
+// deviceFormats queries the full device info, which (unlike enumeration) includes
+// the native data formats on every backend.
+func (a *App) deviceFormats(info *malgo.DeviceInfo) []malgo.DataFormat {
+	if len(info.Formats) > 0 {
+		return info.Formats
+	}
+	full, err := a.malCtx.DeviceInfo(malgo.Playback, info.ID, malgo.Shared)
+	if err != nil {
+		return nil
+	}
+	return full.Formats
+}
+
+// describeDevice builds the descriptor stored in Config for a device
+func (a *App) describeDevice(info *malgo.DeviceInfo) *DeviceDescriptor {
+	var channels uint32
+	for _, f := range a.deviceFormats(info) {
+		if f.Channels > channels {
+			channels = f.Channels
+		}
+	}
+	return &DeviceDescriptor{
+		ID:       info.ID.String(),
+		Name:     info.Name(),
+		Channels: channels,
+		Backend:  backendName(a.backend),
+	}
+}
+
+// resolveDevice finds the playback device for a configured selection.
+// Returns nil for "default"/"none" or when nothing matches.
+func (a *App) resolveDevice(idStr string, desc *DeviceDescriptor) *malgo.DeviceInfo {
+	if !isDeviceSelection(idStr) || a.malCtx == nil {
+		return nil
+	}
+	infos, err := a.malCtx.Devices(malgo.Playback)
+	if err != nil {
+		return nil
+	}
+	return matchDevice(infos, idStr, desc, backendName(a.backend))
+}
+
+// matchDevice walks the fallback ladder: exact backend ID, then the same
+// friendly name, then the closest fuzzy name match.
+func matchDevice(infos []malgo.DeviceInfo, idStr string, desc *DeviceDescriptor, backend string) *malgo.DeviceInfo {
+	// IDs are only meaningful within the backend that issued them
+	if desc == nil || desc.Backend == "" || desc.Backend == backend {
+		for i := range infos {
+			if infos[i].ID.String() == idStr {
+				return &infos[i]
+			}
+		}
+	}
+
+	if desc == nil || desc.Name == "" {
+		return nil
+	}
+
+	for i := range infos {
+		if infos[i].Name() == desc.Name {
+			return &infos[i]
+		}
+	}
+
+	want := deviceNameTokens(desc.Name)
+	var best *malgo.DeviceInfo
+	bestScore := 0.0
+	for i := range infos {
+		score := tokenSimilarity(want, deviceNameTokens(infos[i].Name()))
+		if score > bestScore {
+			best = &infos[i]
+			bestScore = score
+		}
+	}
+	if bestScore < fuzzyNameThreshold {
+		return nil
+	}
+	return best
+}
+
+// deviceNameTokens splits a friendly name into lowercase words, dropping the
+// "2- " style instance prefixes Windows adds when a device is re-enumerated.
+func deviceNameTokens(name string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := make(map[string]bool, len(words))
+	for _, w := range words {
+		if strings.IndexFunc(w, func(r rune) bool { return !unicode.IsDigit(r) }) == -1 && len(w) <= 2 {
+			continue
+		}
+		tokens[w] = true
+	}
+	return tokens
+}
+
+// tokenSimilarity is the Jaccard index of two token sets
+func tokenSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for t := range a {
+		if b[t] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// toAudioFormats converts malgo's native formats for the frontend
+func toAudioFormats(formats []malgo.DataFormat) []AudioFormat {
+	result := make([]AudioFormat, 0, len(formats))
+	for _, f := range formats {
+		name, ok := formatNames[f.Format]
+		if !ok {
+			name = "unknown"
+		}
+		result = append(result, AudioFormat{
+			Format:     name,
+			Channels:   f.Channels,
+			SampleRate: f.SampleRate,
+			Exclusive:  f.Flags&maDataFormatFlagExclusiveMode != 0,
+		})
+	}
+	return result
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// audioWriter streams interleaved float32 samples into an audio file
+type audioWriter interface {
+	WriteSamples(samples []float32) error
+	Close() error
+}
+
+// newAudioWriter creates path and picks the encoder from format ("wav" or "flac")
+func newAudioWriter(path, format string, sampleRate, channels int) (audioWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	var w audioWriter
+	switch format {
+	case "flac":
+		w, err = newFlacWriter(f, sampleRate, channels)
+	default:
+		w, err = newWavWriter(f, sampleRate, channels)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	return w, nil
+}
+
+// toPCM16 converts a float sample to 16-bit PCM with clipping
+func toPCM16(x float32) int16 {
+	if x >= 1 {
+		return math.MaxInt16
+	}
+	if x <= -1 {
+		return -math.MaxInt16
+	}
+	return int16(x * math.MaxInt16)
+}
+
+// wavWriter writes 16-bit PCM WAV. The RIFF sizes are patched in on Close,
+// so the file is only complete once Close returns.
+type wavWriter struct {
+	f        *os.File
+	w        *bufio.Writer
+	channels int
+	bytes    uint32
+	tmp      [2]byte
+}
+
+const wavHeaderSize = 44
+
+func newWavWriter(f *os.File, sampleRate, channels int) (*wavWriter, error) {
+	w := &wavWriter{f: f, w: bufio.NewWriter(f), channels: channels}
+	if err := w.writeHeader(sampleRate); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *wavWriter) writeHeader(sampleRate int) error {
+	var h [wavHeaderSize]byte
+	blockAlign := w.channels * 2
+	copy(h[0:], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:], 36+w.bytes)
+	copy(h[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16) // fmt chunk size
+	binary.LittleEndian.PutUint16(h[20:], 1)  // PCM
+	binary.LittleEndian.PutUint16(h[22:], uint16(w.channels))
+	binary.LittleEndian.PutUint32(h[24:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(h[28:], uint32(sampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(h[32:], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(h[34:], 16) // Bits per sample
+	copy(h[36:], "data")
+	binary.LittleEndian.PutUint32(h[40:], w.bytes)
+	_, err := w.w.Write(h[:])
+	return err
+}
+
+func (w *wavWriter) WriteSamples(samples []float32) error {
+	for _, x := range samples {
+		binary.LittleEndian.PutUint16(w.tmp[:], uint16(toPCM16(x)))
+		if _, err := w.w.Write(w.tmp[:]); err != nil {
+			return err
+		}
+	}
+	w.bytes += uint32(len(samples) * 2)
+	return nil
+}
+
+func (w *wavWriter) Close() error {
+	err := w.w.Flush()
+	if err == nil {
+		// Patch the RIFF and data chunk sizes now that we know them
+		var size [4]byte
+		binary.LittleEndian.PutUint32(size[:], 36+w.bytes)
+		_, err = w.f.WriteAt(size[:], 4)
+		if err == nil {
+			binary.LittleEndian.PutUint32(size[:], w.bytes)
+			_, err = w.f.WriteAt(size[:], 40)
+		}
+	}
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// flacWriter writes 16-bit FLAC using verbatim subframes. It doesn't compress,
+// but it is a valid stream any FLAC decoder can read and needs no extra dependency.
+type flacWriter struct {
+	f        *os.File
+	w        *bufio.Writer
+	channels int
+	pending  []int16 // Interleaved samples waiting for a full block
+	frameNum uint64
+	total    uint64 // Frames written
+	frame    []byte
+}
+
+const (
+	flacBlockSize       = 4096
+	flacStreamInfoStart = 8 // After "fLaC" and the metadata block header
+)
+
+func newFlacWriter(f *os.File, sampleRate, channels int) (*flacWriter, error) {
+	if channels < 1 || channels > 8 {
+		return nil, fmt.Errorf("flac: unsupported channel count %d", channels)
+	}
+	w := &flacWriter{
+		f:        f,
+		w:        bufio.NewWriter(f),
+		channels: channels,
+		pending:  make([]int16, 0, flacBlockSize*channels),
+	}
+
+	var h [flacStreamInfoStart + 34]byte
+	copy(h[0:], "fLaC")
+	h[4] = 0x80 // Last metadata block, type STREAMINFO
+	h[7] = 34   // Block length
+	si := h[flacStreamInfoStart:]
+	binary.BigEndian.PutUint16(si[0:], flacBlockSize)
+	binary.BigEndian.PutUint16(si[2:], flacBlockSize)
+	// Min/max frame size (24 bits each) left as 0 = unknown
+	// Sample rate (20 bits), channels-1 (3 bits), bits per sample-1 (5 bits), total samples (36 bits)
+	packed := uint64(sampleRate)<<44 | uint64(channels-1)<<41 | uint64(16-1)<<36
+	binary.BigEndian.PutUint64(si[10:], packed)
+	// MD5 left as 0 = unknown
+	if _, err := w.w.Write(h[:]); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *flacWriter) WriteSamples(samples []float32) error {
+	for _, x := range samples {
+		w.pending = append(w.pending, toPCM16(x))
+		if len(w.pending) == cap(w.pending) {
+			if err := w.writeFrame(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeFrame encodes pending as one fixed-blocksize frame
+func (w *flacWriter) writeFrame() error {
+	blockSize := len(w.pending) / w.channels
+	if blockSize == 0 {
+		return nil
+	}
+
+	fr := w.frame[:0]
+	fr = append(fr, 0xFF, 0xF8)                   // Sync code, fixed block size
+	fr = append(fr, 0x70)                         // Block size in 16 bits at end of header, sample rate from STREAMINFO
+	fr = append(fr, byte(w.channels-1)<<4|0x4<<1) // Independent channels, 16 bits per sample
+	fr = appendFlacUTF8(fr, w.frameNum)
+	fr = append(fr, byte((blockSize-1)>>8), byte(blockSize-1))
+	fr = append(fr, crc8(fr))
+
+	for c := 0; c < w.channels; c++ {
+		fr = append(fr, 0x02) // VERBATIM subframe, no wasted bits
+		for i := 0; i < blockSize; i++ {
+			s := uint16(w.pending[i*w.channels+c])
+			fr = append(fr, byte(s>>8), byte(s))
+		}
+	}
+	crc := crc16(fr)
+	fr = append(fr, byte(crc>>8), byte(crc))
+
+	w.frame = fr
+	w.pending = w.pending[:0]
+	w.frameNum++
+	w.total += uint64(blockSize)
+	_, err := w.w.Write(fr)
+	return err
+}
+
+func (w *flacWriter) Close() error {
+	err := w.writeFrame()
+	if err == nil {
+		err = w.w.Flush()
+	}
+	if err == nil {
+		// Patch the total sample count (low 36 bits of the packed STREAMINFO word)
+		var packed [8]byte
+		if _, err = w.f.ReadAt(packed[:], flacStreamInfoStart+10); err == nil || err == io.EOF {
+			v := binary.BigEndian.Uint64(packed[:])
+			v = v&^(1<<36-1) | w.total&(1<<36-1)
+			binary.BigEndian.PutUint64(packed[:], v)
+			_, err = w.f.WriteAt(packed[:], flacStreamInfoStart+10)
+		}
+	}
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// appendFlacUTF8 encodes a frame number with FLAC's extended UTF-8 scheme
+func appendFlacUTF8(b []byte, v uint64) []byte {
+	if v < 0x80 {
+		return append(b, byte(v))
+	}
+	// Number of continuation bytes needed
+	n := 1
+	for v >= 1<<(5*n+6) && n < 6 {
+		n++
+	}
+	lead := byte(0xFF<<(7-n)) | byte(v>>(6*n))
+	b = append(b, lead)
+	for i := n - 1; i >= 0; i-- {
+		b = append(b, 0x80|byte(v>>(6*i))&0x3F)
+	}
+	return b
+}
+
+func crc8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
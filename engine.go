@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Everything that changes what a bus plays (starting, stopping, pausing and
+// seeking clips, swapping the live input) is sent to the audio thread as a
+// command. The callback applies its queue before rendering, so a change
+// always lands on a buffer boundary and the state other threads read back
+// is exactly what is being heard. The audio thread reports back through a
+// second queue, which the engine loop turns into closed channels.
+
+type commandKind uint8
+
+const (
+	cmdStart commandKind = iota // Put voice on the bus according to mode
+	cmdStop                     // Fade the current voice out over frames (0 = cut) and drop the queue
+	cmdPause                    // Pause (paused) or resume the voice playing id
+	cmdSeek                     // Move the voice playing id to frames into the clip
+	cmdInput                    // Replace the live input with voice (nil to remove it)
+)
+
+type command struct {
+	kind   commandKind
+	voice  *voice
+	id     string
+	mode   string // Transition mode, for cmdStart
+	frames int64  // Fade length, or the seek target
+	paused bool
+	done   chan struct{} // Closed once applied
+}
+
+// engineEvent is something the audio thread reports back
+type engineEvent struct {
+	done     chan struct{} // Of an applied command, or nil
+	released *voice        // A clip that stopped playing, or nil
+}
+
+const (
+	commandQueueSize = 64
+	eventQueueSize   = 256
+	commandTimeout   = 250 * time.Millisecond // Longest a caller waits for a command to be applied
+	engineInterval   = 5 * time.Millisecond
+)
+
+const (
+	eventsPerCommand = 4 // Most events one command reports: its done, and a cut releasing cur, out and next
+	maxBusVoices     = 3 // cur, out and next, each reported once when released
+)
+
+// spscQueue is a fixed-size lock-free queue for a single producer and a single consumer
+type spscQueue[T any] struct {
+	items []T
+	head  atomic.Uint64 // Next to read
+	tail  atomic.Uint64 // Next to write
+}
+
+func newSPSCQueue[T any](size int) *spscQueue[T] {
+	return &spscQueue[T]{items: make([]T, size)}
+}
+
+// push adds v, or returns false if the queue is full
+func (q *spscQueue[T]) push(v T) bool {
+	tail := q.tail.Load()
+	if tail-q.head.Load() == uint64(len(q.items)) {
+		return false
+	}
+	q.items[tail%uint64(len(q.items))] = v
+	q.tail.Store(tail + 1)
+	return true
+}
+
+// free returns how many more items fit. Producer only.
+func (q *spscQueue[T]) free() int {
+	return len(q.items) - int(q.tail.Load()-q.head.Load())
+}
+
+func (q *spscQueue[T]) pop() (v T, ok bool) {
+	head := q.head.Load()
+	if head == q.tail.Load() {
+		return v, false
+	}
+	i := head % uint64(len(q.items))
+	v = q.items[i]
+	var zero T
+	q.items[i] = zero // Don't keep voices alive from an old slot
+	q.head.Store(head + 1)
+	return v, true
+}
+
+// send queues c for the audio thread and returns a channel that is closed
+// once it has been applied. Caller holds App.audioMu, which makes it the only
+// producer and keeps the device from going away meanwhile. A bus without a
+// running device has nothing to apply to, so the channel is closed at once;
+// so is it if the queue is full and the command dropped, which is counted.
+func (b *bus) send(c command) <-chan struct{} {
+	c.done = make(chan struct{})
+	if b.device == nil || !b.cmds.push(c) {
+		if b.device != nil {
+			b.dropped.Add(1)
+		}
+		if c.kind == cmdStart {
+			c.voice.close()
+		}
+		close(c.done)
+	}
+	return c.done
+}
+
+// apply runs the pending commands. Audio thread only.
+//
+// Every event must get through, or a done channel is never closed and a
+// decoder never released, so a command is only applied while the event
+// queue has room for everything it can report plus a release of each voice
+// the bus may then hold. The rest wait for a later callback; if the engine
+// loop stalls for long, the command queue fills and send drops new ones.
+func (b *bus) apply() {
+	for b.events.free() >= eventsPerCommand+maxBusVoices {
+		c, ok := b.cmds.pop()
+		if !ok {
+			break
+		}
+		switch c.kind {
+		case cmdStart:
+			b.applyStart(c.voice, c.mode, int(c.frames))
+		case cmdStop:
+			b.applyStop(int(c.frames))
+		case cmdPause:
+			if v := b.cur; v != nil && v.id == c.id && v.source != nil {
+				v.source.paused.Store(c.paused)
+			}
+		case cmdSeek:
+			if v := b.cur; v != nil && v.id == c.id && v.source != nil {
+				v.source.seek(c.frames)
+			}
+		case cmdInput:
+			b.in = c.voice
+		}
+		b.events.push(engineEvent{done: c.done})
+	}
+	b.playing.Store(b.cur)
+}
+
+// applyStart puts a voice on the bus. Audio thread only.
+func (b *bus) applyStart(v *voice, mode string, frames int) {
+	switch mode {
+	case transitionQueue:
+		if b.cur != nil {
+			if b.next != nil {
+				b.release(b.next)
+			}
+			b.next = v
+			return
+		}
+	case transitionCrossfade:
+		if b.cur != nil && frames > 0 {
+			if b.out != nil {
+				b.release(b.out)
+			}
+			v.fadeIn = frames
+			b.cur.fadeOut = frames
+			b.out, b.cur = b.cur, v
+			return
+		}
+	}
+	if b.cur != nil {
+		b.release(b.cur)
+	}
+	b.cur = v
+}
+
+// applyStop fades the current voice out over frames (0 = at once) and drops
+// the queue. Audio thread only.
+func (b *bus) applyStop(frames int) {
+	if b.next != nil {
+		b.release(b.next)
+		b.next = nil
+	}
+	if frames <= 0 {
+		for _, v := range []*voice{b.cur, b.out} {
+			if v != nil {
+				b.release(v)
+			}
+		}
+		b.cur, b.out = nil, nil
+		return
+	}
+	if b.cur == nil {
+		return
+	}
+	if b.out != nil {
+		b.release(b.out)
+	}
+	// Keep a fade that is already under way
+	if b.cur.fadeOut == 0 {
+		b.cur.fadeOut = frames
+	}
+	b.out, b.cur = b.cur, nil
+}
+
+// release reports that a clip has stopped playing, for the engine loop to
+// close it. There is always room: see apply. Audio thread only.
+func (b *bus) release(v *voice) {
+	b.events.push(engineEvent{released: v})
+}
+
+// reset drops every voice and pending command. Only called while the bus has
+// no running device, so nothing else is reading the queue.
+func (b *bus) reset() {
+	for {
+		c, ok := b.cmds.pop()
+		if !ok {
+			break
+		}
+		if c.kind == cmdStart {
+			c.voice.close()
+		}
+		close(c.done)
+	}
+	for _, v := range []*voice{b.cur, b.out, b.next} {
+		v.close()
+	}
+	b.cur, b.out, b.next, b.in = nil, nil, nil, nil
+	b.playing.Store(nil)
+}
+
+// sendAll queues the same command on both buses and waits until it has been applied
+func (a *App) sendAll(c command) {
+	a.audioMu.Lock()
+	main, aux := a.mainBus.send(c), a.auxBus.send(c)
+	a.audioMu.Unlock()
+	a.await(main, aux)
+}
+
+// await waits until the audio threads have applied commands, giving up after
+// commandTimeout in case a device has stalled
+func (a *App) await(done ...<-chan struct{}) {
+	timeout := time.NewTimer(commandTimeout)
+	defer timeout.Stop()
+	for _, d := range done {
+		select {
+		case <-d:
+		case <-timeout.C:
+			return
+		}
+	}
+}
+
+// runEngine relays what the audio threads report: it closes the channels of
+// applied commands and wakes the meter loop when a clip stops, so the
+// frontend hears about it straight away
+func (a *App) runEngine(ctx context.Context) {
+	ticker := time.NewTicker(engineInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		released := false
+		for _, b := range []*bus{a.mainBus, a.auxBus} {
+			for {
+				e, ok := b.events.pop()
+				if !ok {
+					break
+				}
+				if e.done != nil {
+					close(e.done)
+				}
+				if e.released != nil {
+					e.released.close()
+					released = true
+				}
+			}
+		}
+		if released {
+			select {
+			case a.meterWake <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gen2brain/malgo"
+	"github.com/gopxl/beep/v2"
+)
+
+// countingCloser counts how many decoders have been closed
+type countingCloser struct{ n *atomic.Int32 }
+
+func (c countingCloser) Close() error {
+	c.n.Add(1)
+	return nil
+}
+
+// closedWithin reports whether every channel is closed before timeout
+func closedWithin(timeout time.Duration, done ...<-chan struct{}) bool {
+	deadline := time.After(timeout)
+	for _, d := range done {
+		select {
+		case <-d:
+		case <-deadline:
+			return false
+		}
+	}
+	return true
+}
+
+func TestEventsSurviveStalledEngine(t *testing.T) {
+	a := NewApp()
+	b := a.mainBus
+	b.device = &malgo.Device{} // Never started: the test is the audio thread
+
+	const clips = 1000
+	var closed atomic.Int32
+	var done []<-chan struct{}
+	out := make([]byte, 64*2*4)
+	for i := range clips {
+		v := &voice{id: fmt.Sprint(i), streamer: beep.Silence(-1), closer: countingCloser{&closed}}
+		done = append(done, b.send(command{kind: cmdStart, voice: v, mode: transitionQueue}))
+		b.onSamples(out, 64, 2)
+	}
+	if b.dropped.Load() == 0 {
+		t.Error("no commands counted as dropped with the engine loop stalled")
+	}
+
+	// Let the engine loop catch up while the callback keeps running
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go a.runEngine(ctx)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				b.onSamples(out, 64, 2)
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+
+	if !closedWithin(2*time.Second, done...) {
+		t.Fatal("a command's done channel was never closed")
+	}
+	if !closedWithin(time.Second, b.send(command{kind: cmdStop})) {
+		t.Fatal("stop not applied")
+	}
+	if !eventually(func() bool { return closed.Load() == clips }) {
+		t.Errorf("%d of %d decoders closed", closed.Load(), clips)
+	}
+}
+
+// startVoice puts an endless clip on the main bus and waits until the audio thread has it
+func startVoice(t *testing.T, a *App, id string, closed *atomic.Int32) *voice {
+	t.Helper()
+	rate := a.mainBus.format.sampleRate
+	src := newClipSource(&sineClip{rate: rate})
+	v := &voice{id: id, streamer: src, source: src, rate: rate, duration: 3600, closer: countingCloser{closed}}
+	sendMain(t, a, command{kind: cmdStart, voice: v, mode: transitionCut})
+	return v
+}
+
+// sendMain sends c to the main bus and waits until it has been applied
+func sendMain(t *testing.T, a *App, c command) {
+	t.Helper()
+	a.audioMu.Lock()
+	done := a.mainBus.send(c)
+	a.audioMu.Unlock()
+	if !closedWithin(time.Second, done) {
+		t.Fatalf("command %d not applied", c.kind)
+	}
+}
+
+func TestEngineStart(t *testing.T) {
+	a := newHeadlessApp(t)
+	var closed atomic.Int32
+	v := startVoice(t, a, "a", &closed)
+	if got := a.mainBus.playing.Load(); got != v {
+		t.Fatalf("playing = %v, want the started voice", got)
+	}
+
+	// A cut start replaces the voice, which is then closed
+	w := startVoice(t, a, "b", &closed)
+	if got := a.mainBus.playing.Load(); got != w {
+		t.Fatalf("playing = %v, want the second voice", got)
+	}
+	if !eventually(func() bool { return closed.Load() == 1 }) {
+		t.Errorf("%d decoders closed, want the replaced one", closed.Load())
+	}
+}
+
+func TestEngineStop(t *testing.T) {
+	a := newHeadlessApp(t)
+	var closed atomic.Int32
+	startVoice(t, a, "a", &closed)
+	sendMain(t, a, command{kind: cmdStop})
+	if got := a.mainBus.playingID(); got != "" {
+		t.Errorf("playingID = %q after stop", got)
+	}
+	if !eventually(func() bool { return closed.Load() == 1 }) {
+		t.Error("stopped voice's decoder not closed")
+	}
+}
+
+func TestEngineSeek(t *testing.T) {
+	a := newHeadlessApp(t)
+	var closed atomic.Int32
+	v := startVoice(t, a, "a", &closed)
+	sendMain(t, a, command{kind: cmdSeek, id: "a", frames: v.frameAt(30)})
+	if pos := v.position(); pos < 30 || pos > 30.5 {
+		t.Errorf("position = %.3f after seeking to 30", pos)
+	}
+
+	// A seek for another clip is ignored
+	sendMain(t, a, command{kind: cmdSeek, id: "b", frames: 0})
+	if pos := v.position(); pos < 30 {
+		t.Errorf("position = %.3f after seeking a clip that isn't playing", pos)
+	}
+}
+
+func TestEnginePause(t *testing.T) {
+	a := newHeadlessApp(t)
+	var closed atomic.Int32
+	v := startVoice(t, a, "a", &closed)
+	sendMain(t, a, command{kind: cmdPause, id: "a", paused: true})
+	if !v.source.paused.Load() {
+		t.Fatal("not paused")
+	}
+	at := v.source.frames.Load()
+	time.Sleep(50 * time.Millisecond)
+	if now := v.source.frames.Load(); now != at {
+		t.Errorf("paused voice moved from frame %d to %d", at, now)
+	}
+
+	sendMain(t, a, command{kind: cmdPause, id: "a"})
+	if v.source.paused.Load() {
+		t.Fatal("still paused")
+	}
+	if !eventually(func() bool { return v.source.frames.Load() > at }) {
+		t.Error("resumed voice doesn't move")
+	}
+}
+
+// eventually polls cond for up to a second
+func eventually(cond func() bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return true
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// watchInterval is how often watched folders are rescanned
+const watchInterval = 5 * time.Second
+
+// audioExtensions are the files a folder scan picks up
+var audioExtensions = map[string]bool{".mp3": true, ".wav": true}
+
+// folderFile is an audio file found by scanAudioFolder
+type folderFile struct {
+	path     string
+	category string // Subfolder it is in, "a/b" style; "" at the top
+	modTime  time.Time
+}
+
+// scanAudioFolder lists the audio files under root, skipping hidden folders
+// and anything that can't be read
+func scanAudioFolder(root string) ([]folderFile, error) {
+	var files []folderFile
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !audioExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		f := folderFile{path: path, modTime: info.ModTime()}
+		if rel, err := filepath.Rel(root, filepath.Dir(path)); err == nil && rel != "." {
+			f.category = filepath.ToSlash(rel)
+		}
+		files = append(files, f)
+		return nil
+	})
+	return files, err
+}
+
+// pathKey compares file paths the way Windows does, ignoring case
+func pathKey(path string) string {
+	return strings.ToLower(filepath.Clean(path))
+}
+
+// listedPaths returns the keys of the paths already in the list. Caller holds a.mu.
+func (a *App) listedPaths() map[string]bool {
+	listed := make(map[string]bool, len(a.Config.AudioList))
+	for _, item := range a.Config.AudioList {
+		listed[pathKey(item.Path)] = true
+	}
+	return listed
+}
+
+// importFolderFile validates and adds one scanned file, filed under its subfolder
+func (a *App) importFolderFile(f folderFile) ImportEntry {
+	item, entry := a.importAudioFile(f.path)
+	if item != nil {
+		a.mu.Lock()
+		item.Category = f.category
+		a.mu.Unlock()
+	}
+	return entry
+}
+
+// ImportFolder asks for a folder and imports every audio file in it and its
+// subfolders, which become categories. Returns nil if cancelled.
+func (a *App) ImportFolder() *ImportReport {
+	dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Select Audio Folder",
+	})
+	if err != nil || dir == "" {
+		return nil
+	}
+	report := a.importFolder(dir)
+	return &report
+}
+
+// importFolder imports the audio files under dir that aren't in the list yet
+func (a *App) importFolder(dir string) ImportReport {
+	a.folderMu.Lock()
+	defer a.folderMu.Unlock()
+
+	report := ImportReport{Source: filepath.Base(dir), Entries: []ImportEntry{}}
+	files, err := scanAudioFolder(dir)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	a.mu.Lock()
+	listed := a.listedPaths()
+	a.mu.Unlock()
+
+	for _, f := range files {
+		if listed[pathKey(f.path)] {
+			continue
+		}
+		report.add(a.importFolderFile(f))
+	}
+
+	if report.Added > 0 {
+		a.mu.Lock()
+		a.saveConfig()
+		a.mu.Unlock()
+		runtime.EventsEmit(a.ctx, "audios-changed")
+	}
+	return report
+}
+
+// GetWatchFolders returns the folders kept in sync with the list
+func (a *App) GetWatchFolders() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return slices.Clone(a.Config.WatchFolders)
+}
+
+// AddWatchFolder asks for a folder to watch: audio files added to it (or its
+// subfolders) are imported, and clips whose files are removed from it leave
+// the list. Deleting such a clip from the list only lasts until the next
+// scan; delete or move the file instead. Returns the report of importing
+// what is already there, or nil if cancelled.
+func (a *App) AddWatchFolder() *ImportReport {
+	dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Select Folder to Watch",
+	})
+	if err != nil || dir == "" {
+		return nil
+	}
+	report := a.addWatchFolder(filepath.Clean(dir))
+	return &report
+}
+
+func (a *App) addWatchFolder(dir string) ImportReport {
+	a.mu.Lock()
+	for _, w := range a.Config.WatchFolders {
+		if _, inside := pathWithin(w, dir); inside || pathKey(w) == pathKey(dir) {
+			a.mu.Unlock()
+			return ImportReport{Source: filepath.Base(dir), Entries: []ImportEntry{}, Error: "该文件夹已在监视中"}
+		}
+	}
+	a.Config.WatchFolders = append(a.Config.WatchFolders, dir)
+	a.saveConfig()
+	a.mu.Unlock()
+
+	// Import what is already there without waiting for the next scan
+	return a.importFolder(dir)
+}
+
+// RemoveWatchFolder stops watching a folder. Its clips stay in the list.
+func (a *App) RemoveWatchFolder(dir string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.Config.WatchFolders = slices.DeleteFunc(a.Config.WatchFolders, func(w string) bool {
+		return pathKey(w) == pathKey(dir)
+	})
+	a.saveConfig()
+}
+
+// runFolderWatcher rescans the watched folders every watchInterval
+func (a *App) runFolderWatcher(ctx context.Context) {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	// Files that failed to import, by modification time, so they are only
+	// tried again once they change (e.g. a sync finishing)
+	failed := make(map[string]time.Time)
+	for {
+		if a.syncWatchFolders(failed) {
+			runtime.EventsEmit(a.ctx, "audios-changed")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncWatchFolders brings the list in line with the watched folders. A
+// folder that can't be reached (an unplugged or unsynced drive) is left
+// alone rather than taken to be empty. Reports whether the list changed.
+func (a *App) syncWatchFolders(failed map[string]time.Time) bool {
+	a.folderMu.Lock()
+	defer a.folderMu.Unlock()
+
+	a.mu.Lock()
+	folders := slices.Clone(a.Config.WatchFolders)
+	listed := a.listedPaths()
+	a.mu.Unlock()
+
+	changed := false
+	var reachable []string
+	present := make(map[string]bool)
+	for _, dir := range folders {
+		files, err := scanAudioFolder(dir)
+		if err != nil {
+			continue
+		}
+		reachable = append(reachable, dir)
+		for _, f := range files {
+			key := pathKey(f.path)
+			present[key] = true
+			if listed[key] {
+				continue
+			}
+			if t, ok := failed[key]; ok && t.Equal(f.modTime) {
+				continue
+			}
+			if entry := a.importFolderFile(f); entry.Status == importFailed {
+				failed[key] = f.modTime
+				runtime.LogWarningf(a.ctx, "Watch folder: skipping %s: %s", f.path, entry.Message)
+				continue
+			}
+			delete(failed, key)
+			listed[key] = true
+			changed = true
+		}
+	}
+
+	// Drop clips whose files are gone from a reachable watched folder. The
+	// scan skips hidden folders, so make sure the file really is missing.
+	a.mu.Lock()
+	var gone []string
+	for _, item := range a.Config.AudioList {
+		if present[pathKey(item.Path)] {
+			continue
+		}
+		for _, dir := range reachable {
+			if _, inside := pathWithin(dir, item.Path); inside {
+				gone = append(gone, item.Path)
+				break
+			}
+		}
+	}
+	a.mu.Unlock()
+	gone = slices.DeleteFunc(gone, func(path string) bool {
+		_, err := os.Stat(path)
+		return !os.IsNotExist(err)
+	})
+
+	a.mu.Lock()
+	var removed []string
+	a.Config.AudioList = slices.DeleteFunc(a.Config.AudioList, func(item *AudioItem) bool {
+		if slices.Contains(gone, item.Path) {
+			removed = append(removed, item.ID)
+			return true
+		}
+		return false
+	})
+	for _, id := range removed {
+		a.removeFromGroups(id)
+	}
+	changed = changed || len(removed) > 0
+	if changed {
+		a.saveConfig()
+	}
+	a.mu.Unlock()
+	return changed
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeClipAt writes a short clip to path, making its folder as needed
+func writeClipAt(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	samples := make([]float32, 4800*2)
+	for i := range samples {
+		samples[i] = 0.1
+	}
+	if err := writeClip(path, samples, 48000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// clipAt returns the listed clip for path, or nil
+func clipAt(a *App, path string) *AudioItem {
+	for _, item := range a.Config.AudioList {
+		if pathKey(item.Path) == pathKey(path) {
+			return item
+		}
+	}
+	return nil
+}
+
+func TestScanAudioFolder(t *testing.T) {
+	dir := t.TempDir()
+	writeClipAt(t, filepath.Join(dir, "top.wav"))
+	writeClipAt(t, filepath.Join(dir, "a", "b", "deep.WAV"))
+	writeClipAt(t, filepath.Join(dir, ".hidden", "skipped.wav"))
+	writeClipAt(t, filepath.Join(dir, "a", ".cache", "skipped.wav"))
+	writeFile(t, filepath.Join(dir, "a", "notes.txt"), "not audio")
+
+	files, err := scanAudioFolder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, f := range files {
+		rel, _ := filepath.Rel(dir, f.path)
+		got[filepath.ToSlash(rel)] = f.category
+	}
+	want := map[string]string{"top.wav": "", "a/b/deep.WAV": "a/b"}
+	if len(got) != len(want) {
+		t.Errorf("scanned %v, want %v", got, want)
+	}
+	for path, category := range want {
+		if c, ok := got[path]; !ok || c != category {
+			t.Errorf("%s: category %q (found %v), want %q", path, c, ok, category)
+		}
+	}
+
+	if _, err := scanAudioFolder(filepath.Join(dir, "missing")); err == nil {
+		t.Error("scanning a missing folder succeeded")
+	}
+}
+
+func TestSyncWatchFolders(t *testing.T) {
+	base := t.TempDir()
+	watched := filepath.Join(base, "watched")
+	removable := filepath.Join(base, "removable")
+	writeClipAt(t, filepath.Join(watched, "top.wav"))
+	writeClipAt(t, filepath.Join(watched, "a", "b", "deep.wav"))
+	writeClipAt(t, filepath.Join(watched, ".hidden", "kept.wav"))
+	writeClipAt(t, filepath.Join(removable, "away.wav"))
+
+	a := NewApp()
+	a.Config.WatchFolders = []string{watched, removable}
+	failed := map[string]time.Time{}
+	if !a.syncWatchFolders(failed) {
+		t.Fatal("first sync reports no change")
+	}
+	top, deep := clipAt(a, filepath.Join(watched, "top.wav")), clipAt(a, filepath.Join(watched, "a", "b", "deep.wav"))
+	if top == nil || deep == nil || clipAt(a, filepath.Join(removable, "away.wav")) == nil {
+		t.Fatalf("clips after the first sync: %v", a.Config.AudioList)
+	}
+	if top.Category != "" || deep.Category != "a/b" {
+		t.Errorf("categories %q and %q, want \"\" and \"a/b\"", top.Category, deep.Category)
+	}
+	if clipAt(a, filepath.Join(watched, ".hidden", "kept.wav")) != nil {
+		t.Error("clip in a hidden folder imported")
+	}
+	if a.syncWatchFolders(failed) {
+		t.Error("second sync with nothing new reports a change")
+	}
+
+	// A clip added by hand from a hidden folder stays: its file is still there
+	hidden, _ := a.importAudioFile(filepath.Join(watched, ".hidden", "kept.wav"))
+	a.Config.Groups = []*ClipGroup{{ID: "g", Items: []GroupItem{{ID: deep.ID}, {ID: top.ID}}}}
+
+	// The watched folder loses a file, and the other folder's drive goes away
+	if err := os.Remove(filepath.Join(watched, "a", "b", "deep.wav")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(removable, removable+"-unplugged"); err != nil {
+		t.Fatal(err)
+	}
+	if !a.syncWatchFolders(failed) {
+		t.Error("sync after a file was deleted reports no change")
+	}
+	if clipAt(a, filepath.Join(watched, "a", "b", "deep.wav")) != nil {
+		t.Error("clip whose file was deleted still listed")
+	}
+	if got := a.Config.Groups[0].Items; len(got) != 1 || got[0].ID != top.ID {
+		t.Errorf("group items = %v, want only the remaining clip", got)
+	}
+	if clipAt(a, filepath.Join(removable, "away.wav")) == nil {
+		t.Error("clip in an unreachable watch folder removed")
+	}
+	if !slices.Contains(a.Config.AudioList, hidden) {
+		t.Error("clip from a hidden folder removed though its file is there")
+	}
+}
@@ -72,6 +72,9 @@ async function loadAudios() {
             
             // Load devices
             await loadDevices(conf.main_device, conf.aux_device);
+            await loadLibraryRoot();
+            await loadWatchFolders();
+            loadImportSettings(conf.import);
         } else {
             audios = await window.go.main.App.GetAudios();
             await loadDevices();
@@ -99,14 +102,15 @@ async function loadDevices(currentMain, currentAux) {
 
         if (devs && devs.length > 0) {
             devs.forEach(d => {
+                const label = d.is_default ? d.name + " (默认)" : d.name;
                 const opt1 = document.createElement('option');
                 opt1.value = d.id;
-                opt1.text = d.name;
+                opt1.text = label;
                 mainSelect.appendChild(opt1);
                 
                 const opt2 = document.createElement('option');
                 opt2.value = d.id;
-                opt2.text = d.name;
+                opt2.text = label;
                 auxSelect.appendChild(opt2);
             });
         }
@@ -129,6 +133,95 @@ async function saveAudioSettings() {
     await window.go.main.App.SetAudioSettings(mainDev, auxDev, parseFloat(vol));
 }
 
+async function loadLibraryRoot() {
+    try {
+        const root = await window.go.main.App.GetLibraryRoot();
+        const el = document.getElementById('library-root');
+        el.innerText = root;
+        el.title = root;
+    } catch (e) {
+        console.error("Failed to load library root", e);
+    }
+}
+
+// The library folder was moved: point every clip in it at the new place
+async function relocateLibrary() {
+    try {
+        const result = await window.go.main.App.RelocateLibraryRoot();
+        if (result === "OK") {
+            showNotification("音效库位置已更新", 'success');
+        } else if (result) {
+            showNotification(result, 'error');
+        }
+        await loadLibraryRoot();
+    } catch (err) {
+        showNotification("更改位置失败: " + err, 'error');
+    }
+}
+
+function loadImportSettings(settings) {
+    if (!settings) return;
+    document.getElementById('import-max-size').value = settings.max_size_mb || 0;
+    document.getElementById('import-max-seconds').value = settings.max_seconds || 0;
+    document.getElementById('import-allow-over').checked = settings.allow_over_limit;
+}
+
+async function saveImportSettings() {
+    await window.go.main.App.SetImportSettings({
+        max_size_mb: parseFloat(document.getElementById('import-max-size').value) || 0,
+        max_seconds: parseFloat(document.getElementById('import-max-seconds').value) || 0,
+        allow_over_limit: document.getElementById('import-allow-over').checked,
+    });
+}
+
+async function loadWatchFolders() {
+    try {
+        const folders = await window.go.main.App.GetWatchFolders();
+        const list = document.getElementById('watch-folder-list');
+        list.innerHTML = '';
+        if (!folders || folders.length === 0) {
+            list.innerHTML = '<li class="empty">未监视任何文件夹</li>';
+            return;
+        }
+        folders.forEach(dir => {
+            const li = document.createElement('li');
+            const path = document.createElement('span');
+            path.innerText = dir;
+            path.title = dir;
+            const remove = document.createElement('button');
+            remove.className = 'btn-danger';
+            remove.innerText = '移除';
+            remove.onclick = () => removeWatchFolder(dir);
+            li.appendChild(path);
+            li.appendChild(remove);
+            list.appendChild(li);
+        });
+    } catch (e) {
+        console.error("Failed to load watch folders", e);
+    }
+}
+
+async function addWatchFolder() {
+    try {
+        // null if the dialog was cancelled
+        const report = await window.go.main.App.AddWatchFolder();
+        if (report && report.error) {
+            showNotification(report.error, 'error');
+        } else if (report) {
+            handleImportReport(report, "已开始监视文件夹");
+        }
+        await loadWatchFolders();
+    } catch (err) {
+        showNotification("添加失败: " + err, 'error');
+    }
+}
+
+// The clips already imported from it stay in the list
+async function removeWatchFolder(dir) {
+    await window.go.main.App.RemoveWatchFolder(dir);
+    await loadWatchFolders();
+}
+
 async function resetAudio() {
     // console.log("Resetting audio...");
     try {
@@ -151,16 +244,10 @@ window.resetAudio = resetAudio;
 
 async function importAudio() {
     try {
-        const result = await window.go.main.App.ImportAudioFile();
-        // Always reload audios because ImportAudioFile might have added some valid files
-        // even if it returned errors for others.
-        if (result && result.startsWith("Error")) {
-            showNotification(result, 'error');
-        } else if (result) {
-            showNotification("添加成功", 'success');
-        }
-        // Always reload regardless of result (unless user cancelled dialog which returns empty string)
-        if (result !== "") {
+        const report = await window.go.main.App.ImportAudioFile();
+        // null if the dialog was cancelled
+        if (report) {
+            handleImportReport(report);
             loadAudios();
         }
     } catch (err) {
@@ -169,6 +256,138 @@ async function importAudio() {
     }
 }
 
+// A clean import gets a notification; anything that failed or was flagged gets the full report
+function handleImportReport(report, success = "添加成功") {
+    if (!report.error && report.failed === 0 && !(report.entries || []).some(e => e.status !== 'added')) {
+        showNotification(success, 'success');
+    } else {
+        showImportReport(report);
+    }
+}
+
+// Soundboard packs (.dtpack)
+async function exportPack() {
+    try {
+        const result = await window.go.main.App.ExportPack();
+        if (result === "OK") {
+            showNotification("导出成功", 'success');
+        } else if (result) {
+            showNotification(result, 'error');
+        }
+    } catch (err) {
+        showNotification("导出失败: " + err, 'error');
+    }
+}
+
+function showPackModal() {
+    document.getElementById('pack-modal').classList.add('active');
+}
+
+function closePackModal() {
+    document.getElementById('pack-modal').classList.remove('active');
+}
+
+async function importPack() {
+    const options = {
+        replace: document.getElementById('pack-replace-checkbox').checked,
+        hotkeys: document.getElementById('pack-rebind-checkbox').checked ? 'rebind' : 'skip',
+    };
+    closePackModal();
+    try {
+        const report = await window.go.main.App.ImportPack(options);
+        if (report) handleImportReport(report, "导入成功");
+    } catch (err) {
+        showNotification("导入失败: " + err, 'error');
+    }
+}
+
+async function importFolder() {
+    try {
+        const report = await window.go.main.App.ImportFolder();
+        if (report) showImportReport(report);
+    } catch (err) {
+        showNotification("导入失败: " + err, 'error');
+    }
+}
+
+// Sound lists from other soundboards (Soundpad .spl, CSV, JSON)
+async function importSoundList() {
+    try {
+        const report = await window.go.main.App.ImportSoundList();
+        if (report) showImportReport(report);
+    } catch (err) {
+        showNotification("导入失败: " + err, 'error');
+    }
+}
+
+function showImportReport(report) {
+    document.getElementById('import-report-title').innerText = report.source ? "导入结果 - " + report.source : "导入结果";
+    document.getElementById('import-report-summary').innerText = report.error
+        ? "无法读取: " + report.error
+        : `成功 ${report.added} 个，失败 ${report.failed} 个`;
+
+    const list = document.getElementById('import-report-list');
+    list.innerHTML = '';
+    (report.entries || []).forEach(e => {
+        const li = document.createElement('li');
+        li.className = e.status;
+        li.innerText = e.name || e.path;
+        li.title = e.path;
+        if (e.message) {
+            const msg = document.createElement('span');
+            msg.className = 'message';
+            msg.innerText = e.message;
+            li.appendChild(msg);
+        }
+        list.appendChild(li);
+    });
+    document.getElementById('import-report-modal').classList.add('active');
+}
+
+function closeImportReport() {
+    document.getElementById('import-report-modal').classList.remove('active');
+}
+
+let clipRecording = false;
+
+async function toggleClipRecording() {
+    const btn = document.getElementById('clip-record-btn');
+    try {
+        if (!clipRecording) {
+            const result = await window.go.main.App.StartClipRecording("");
+            if (result.startsWith("Error")) {
+                showNotification(result, 'error');
+                return;
+            }
+            clipRecording = true;
+            btn.classList.add('recording');
+            btn.innerText = '■ 停止';
+        } else {
+            clipRecording = false;
+            btn.classList.remove('recording');
+            btn.style.removeProperty('--level');
+            btn.innerText = '● 录音';
+            const entry = await window.go.main.App.StopClipRecording();
+            if (entry.status === 'failed') {
+                showNotification(entry.message, 'error');
+            } else {
+                showNotification(entry.message ? "录音已添加: " + entry.message : "录音已添加", 'success');
+                loadAudios();
+            }
+        }
+    } catch (err) {
+        console.error(err);
+        showNotification("录音失败: " + err, 'error');
+    }
+}
+
+function onClipRecordingLevel(level) {
+    if (!clipRecording) return;
+    const btn = document.getElementById('clip-record-btn');
+    btn.style.setProperty('--level', Math.min(100, level.peak * 100) + '%');
+    btn.innerText = (level.full ? '■ 停止 (已满) ' : '■ 停止 ') + level.seconds.toFixed(1) + 's';
+}
+
 async function deleteAudio(id) {
     try {
         await window.go.main.App.DeleteAudio(id);
@@ -203,7 +422,7 @@ function renderImportList() {
         tr.addEventListener('mousedown', handleRowMouseDown);
 
         tr.innerHTML = `
-            <td class="drag-handle" style="cursor: grab;">${formatName(item.name)}</td>
+            <td class="drag-handle" style="cursor: grab;"></td>
             <td>${item.duration}</td>
             <td>
                 <input type="text" class="hotkey-input" 
@@ -219,10 +438,37 @@ function renderImportList() {
                 <button class="btn-danger" onclick="deleteAudio('${item.id}')">删除</button>
             </td>
         `;
+        // Folder and file names go in as text, not markup
+        const nameCell = tr.querySelector('.drag-handle');
+        if (item.category) {
+            const tag = document.createElement('span');
+            tag.className = 'category-tag';
+            tag.textContent = item.category;
+            nameCell.appendChild(tag);
+        }
+        nameCell.appendChild(document.createTextNode(formatName(item.name)));
         tbody.appendChild(tr);
     });
 }
 
+// Show each playing clip's progress as a fill behind its row
+function onPlaybackStatus(status) {
+    const playing = {};
+    (status.playing || []).forEach(v => {
+        if (!(v.id in playing) || v.bus === 'main') playing[v.id] = v;
+    });
+    document.querySelectorAll('#import-list .audio-row').forEach(tr => {
+        const v = playing[tr.getAttribute('data-id')];
+        if (v && v.duration > 0) {
+            tr.classList.add('playing');
+            tr.style.setProperty('--progress', (v.position / v.duration * 100) + '%');
+        } else {
+            tr.classList.remove('playing');
+            tr.style.removeProperty('--progress');
+        }
+    });
+}
+
 // Global variables for sorting
 let dragSrcEl = null;
 let dragPlaceholder = null;
@@ -577,12 +823,8 @@ function setupDragAndDrop() {
         }
 
         if (validPaths.length > 0) {
-             window.go.main.App.ImportAudioFiles(validPaths).then(result => {
-                 if (result && result.startsWith("Error")) {
-                     showNotification(result, 'error');
-                 } else if (result) {
-                     showNotification("添加成功", 'success');
-                 }
+             window.go.main.App.ImportAudioFiles(validPaths).then(report => {
+                 handleImportReport(report);
                  loadAudios(); // Always reload
              });
         }
@@ -891,5 +1133,25 @@ const initInterval = setInterval(() => {
     if (window.go && window.go.main && window.go.main.App) {
         clearInterval(initInterval);
         loadAudios();
+        // Clips added by the backend (e.g. instant replay)
+        window.runtime.EventsOn("audios-changed", loadAudios);
+        window.runtime.EventsOn("clip-recording-level", onClipRecordingLevel);
+        window.runtime.EventsOn("playback-status", onPlaybackStatus);
+        window.runtime.EventsOn("config-error", (msg) => showNotification(msg, 'error'));
+        checkConfigStatus();
     }
 }, 100);
+
+// Tell the user if the config was damaged and what was loaded instead
+async function checkConfigStatus() {
+    const status = await window.go.main.App.GetConfigStatus();
+    if (status.newer_version) {
+        showNotification(status.load_error, 'error');
+    } else if (status.load_error) {
+        const recovered = status.recovered_from ? "已从备份 " + status.recovered_from + " 恢复" : "已使用默认设置";
+        showNotification("配置文件损坏，" + recovered, 'error');
+    }
+    if (status.save_error) {
+        showNotification("保存配置失败: " + status.save_error, 'error');
+    }
+}
@@ -2,46 +2,162 @@
 // This file is automatically generated. DO NOT EDIT
 import {main} from '../models';
 
+export function AddWatchFolder():Promise<main.ImportReport>;
+
+export function CancelClipRecording():Promise<void>;
+
 export function CheckForUpdates():Promise<main.CheckUpdateResult>;
 
+export function ChooseRecordingFolder():Promise<string>;
+
 export function DeleteAudio(arg1:string):Promise<void>;
 
+export function DeleteEffectPreset(arg1:string):Promise<void>;
+
+export function DeleteGroup(arg1:string):Promise<void>;
+
+export function ExportPack():Promise<string>;
+
+export function GetAudioBackends():Promise<Array<string>>;
+
 export function GetAudioDevices():Promise<Array<main.AudioDevice>>;
 
+export function GetAudioStatus():Promise<main.AudioStatus>;
+
 export function GetAudios():Promise<Array<main.AudioItem>>;
 
+export function GetCaptureDevices():Promise<Array<main.AudioDevice>>;
+
 export function GetConfig():Promise<main.Config>;
 
+export function GetConfigStatus():Promise<main.ConfigStatus>;
+
+export function GetEffectDefaults():Promise<Record<string, Record<string, number>>>;
+
+export function GetEffectPresets():Promise<Array<main.EffectPreset>>;
+
+export function GetGroups():Promise<Array<main.ClipGroup>>;
+
+export function GetImportSettings():Promise<main.ImportSettings>;
+
+export function GetLibraryRoot():Promise<string>;
+
+export function GetOutputMeters():Promise<main.OutputMeters>;
+
+export function GetOutputSettings():Promise<main.OutputSettings>;
+
+export function GetPlaybackSettings():Promise<main.PlaybackSettings>;
+
+export function GetPlaybackStatus():Promise<main.PlaybackStatus>;
+
+export function GetRecordingSettings():Promise<main.RecordingSettings>;
+
+export function GetRecordingStatus():Promise<main.RecordingStatus>;
+
+export function GetReplaySettings():Promise<main.ReplaySettings>;
+
+export function GetTransportHotkeys():Promise<main.TransportHotkeys>;
+
+export function GetVoiceSettings():Promise<main.VoiceSettings>;
+
+export function GetWatchFolders():Promise<Array<string>>;
+
 export function Hide():Promise<void>;
 
-export function ImportAudioFile():Promise<string>;
+export function ImportAudioFile():Promise<main.ImportReport>;
+
+export function ImportAudioFiles(arg1:Array<string>):Promise<main.ImportReport>;
 
-export function ImportAudioFiles(arg1:Array<string>):Promise<string>;
+export function ImportFolder():Promise<main.ImportReport>;
+
+export function ImportPack(arg1:main.PackImportOptions):Promise<main.ImportReport>;
+
+export function ImportSoundList():Promise<main.ImportReport>;
 
 export function Minimise():Promise<void>;
 
 export function OpenURL(arg1:string):Promise<void>;
 
+export function PanicStop():Promise<void>;
+
+export function PauseAudio(arg1:string):Promise<void>;
+
 export function PlayAudioID(arg1:string):Promise<void>;
 
+export function PlayGroup(arg1:string):Promise<void>;
+
+export function PreviewAudioEffects(arg1:string,arg2:Array<main.EffectConfig>):Promise<void>;
+
 export function Quit():Promise<void>;
 
+export function RelocateLibraryRoot():Promise<string>;
+
+export function RemoveWatchFolder(arg1:string):Promise<void>;
+
 export function ResetAudio():Promise<void>;
 
+export function ResetClipCounters():Promise<void>;
+
+export function ResetGroup(arg1:string):Promise<void>;
+
+export function ResumeAudio(arg1:string):Promise<void>;
+
+export function SaveEffectPreset(arg1:main.EffectPreset):Promise<main.EffectPreset>;
+
+export function SaveGroup(arg1:main.ClipGroup):Promise<main.ClipGroup>;
+
+export function SaveReplay():Promise<main.ImportEntry>;
+
 export function SaveSettings(arg1:string,arg2:boolean):Promise<void>;
 
 export function SaveSidebarState(arg1:boolean):Promise<void>;
 
 export function SaveWindowSize(arg1:number,arg2:number):Promise<void>;
 
+export function SeekAudio(arg1:string,arg2:number):Promise<void>;
+
+export function SelectVoicePreset(arg1:string):Promise<void>;
+
+export function SetAudioBackend(arg1:string):Promise<void>;
+
+export function SetAudioEffects(arg1:string,arg2:Array<main.EffectConfig>):Promise<void>;
+
 export function SetAudioSettings(arg1:string,arg2:string,arg3:number):Promise<void>;
 
+export function SetAudioTransition(arg1:string,arg2:string,arg3:number):Promise<void>;
+
+export function SetImportSettings(arg1:main.ImportSettings):Promise<void>;
+
+export function SetOutputSettings(arg1:main.OutputSettings):Promise<void>;
+
+export function SetPlaybackSettings(arg1:main.PlaybackSettings):Promise<void>;
+
+export function SetRecordingSettings(arg1:main.RecordingSettings):Promise<void>;
+
+export function SetReplaySettings(arg1:main.ReplaySettings):Promise<void>;
+
+export function SetTransportHotkeys(arg1:main.TransportHotkeys):Promise<void>;
+
+export function SetVoiceSettings(arg1:main.VoiceSettings):Promise<void>;
+
 export function Show():Promise<void>;
 
+export function StartClipRecording(arg1:string):Promise<string>;
+
+export function StartRecording():Promise<main.RecordingStatus>;
+
 export function StartUpdate(arg1:string):Promise<void>;
 
+export function StopAllAudio():Promise<void>;
+
+export function StopClipRecording():Promise<main.ImportEntry>;
+
+export function StopRecording():Promise<main.RecordingStatus>;
+
 export function ToggleMaximise():Promise<void>;
 
+export function ToggleRecording():Promise<main.RecordingStatus>;
+
 export function UpdateAudioOrder(arg1:Array<string>):Promise<void>;
 
 export function UpdateHotkey(arg1:string,arg2:string):Promise<void>;
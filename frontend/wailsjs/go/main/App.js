@@ -2,26 +2,126 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AddWatchFolder() {
+  return window['go']['main']['App']['AddWatchFolder']();
+}
+
+export function CancelClipRecording() {
+  return window['go']['main']['App']['CancelClipRecording']();
+}
+
 export function CheckForUpdates() {
   return window['go']['main']['App']['CheckForUpdates']();
 }
 
+export function ChooseRecordingFolder() {
+  return window['go']['main']['App']['ChooseRecordingFolder']();
+}
+
 export function DeleteAudio(arg1) {
   return window['go']['main']['App']['DeleteAudio'](arg1);
 }
 
+export function DeleteEffectPreset(arg1) {
+  return window['go']['main']['App']['DeleteEffectPreset'](arg1);
+}
+
+export function DeleteGroup(arg1) {
+  return window['go']['main']['App']['DeleteGroup'](arg1);
+}
+
+export function ExportPack() {
+  return window['go']['main']['App']['ExportPack']();
+}
+
+export function GetAudioBackends() {
+  return window['go']['main']['App']['GetAudioBackends']();
+}
+
 export function GetAudioDevices() {
   return window['go']['main']['App']['GetAudioDevices']();
 }
 
+export function GetAudioStatus() {
+  return window['go']['main']['App']['GetAudioStatus']();
+}
+
 export function GetAudios() {
   return window['go']['main']['App']['GetAudios']();
 }
 
+export function GetCaptureDevices() {
+  return window['go']['main']['App']['GetCaptureDevices']();
+}
+
 export function GetConfig() {
   return window['go']['main']['App']['GetConfig']();
 }
 
+export function GetConfigStatus() {
+  return window['go']['main']['App']['GetConfigStatus']();
+}
+
+export function GetEffectDefaults() {
+  return window['go']['main']['App']['GetEffectDefaults']();
+}
+
+export function GetEffectPresets() {
+  return window['go']['main']['App']['GetEffectPresets']();
+}
+
+export function GetGroups() {
+  return window['go']['main']['App']['GetGroups']();
+}
+
+export function GetImportSettings() {
+  return window['go']['main']['App']['GetImportSettings']();
+}
+
+export function GetLibraryRoot() {
+  return window['go']['main']['App']['GetLibraryRoot']();
+}
+
+export function GetOutputMeters() {
+  return window['go']['main']['App']['GetOutputMeters']();
+}
+
+export function GetOutputSettings() {
+  return window['go']['main']['App']['GetOutputSettings']();
+}
+
+export function GetPlaybackSettings() {
+  return window['go']['main']['App']['GetPlaybackSettings']();
+}
+
+export function GetPlaybackStatus() {
+  return window['go']['main']['App']['GetPlaybackStatus']();
+}
+
+export function GetRecordingSettings() {
+  return window['go']['main']['App']['GetRecordingSettings']();
+}
+
+export function GetRecordingStatus() {
+  return window['go']['main']['App']['GetRecordingStatus']();
+}
+
+export function GetReplaySettings() {
+  return window['go']['main']['App']['GetReplaySettings']();
+}
+
+export function GetTransportHotkeys() {
+  return window['go']['main']['App']['GetTransportHotkeys']();
+}
+
+export function GetVoiceSettings() {
+  return window['go']['main']['App']['GetVoiceSettings']();
+}
+
+export function GetWatchFolders() {
+  return window['go']['main']['App']['GetWatchFolders']();
+}
+
 export function Hide() {
   return window['go']['main']['App']['Hide']();
 }
@@ -34,6 +134,18 @@ export function ImportAudioFiles(arg1) {
   return window['go']['main']['App']['ImportAudioFiles'](arg1);
 }
 
+export function ImportFolder() {
+  return window['go']['main']['App']['ImportFolder']();
+}
+
+export function ImportPack(arg1) {
+  return window['go']['main']['App']['ImportPack'](arg1);
+}
+
+export function ImportSoundList() {
+  return window['go']['main']['App']['ImportSoundList']();
+}
+
 export function Minimise() {
   return window['go']['main']['App']['Minimise']();
 }
@@ -42,18 +154,66 @@ export function OpenURL(arg1) {
   return window['go']['main']['App']['OpenURL'](arg1);
 }
 
+export function PanicStop() {
+  return window['go']['main']['App']['PanicStop']();
+}
+
+export function PauseAudio(arg1) {
+  return window['go']['main']['App']['PauseAudio'](arg1);
+}
+
 export function PlayAudioID(arg1) {
   return window['go']['main']['App']['PlayAudioID'](arg1);
 }
 
+export function PlayGroup(arg1) {
+  return window['go']['main']['App']['PlayGroup'](arg1);
+}
+
+export function PreviewAudioEffects(arg1, arg2) {
+  return window['go']['main']['App']['PreviewAudioEffects'](arg1, arg2);
+}
+
 export function Quit() {
   return window['go']['main']['App']['Quit']();
 }
 
+export function RelocateLibraryRoot() {
+  return window['go']['main']['App']['RelocateLibraryRoot']();
+}
+
+export function RemoveWatchFolder(arg1) {
+  return window['go']['main']['App']['RemoveWatchFolder'](arg1);
+}
+
 export function ResetAudio() {
   return window['go']['main']['App']['ResetAudio']();
 }
 
+export function ResetClipCounters() {
+  return window['go']['main']['App']['ResetClipCounters']();
+}
+
+export function ResetGroup(arg1) {
+  return window['go']['main']['App']['ResetGroup'](arg1);
+}
+
+export function ResumeAudio(arg1) {
+  return window['go']['main']['App']['ResumeAudio'](arg1);
+}
+
+export function SaveEffectPreset(arg1) {
+  return window['go']['main']['App']['SaveEffectPreset'](arg1);
+}
+
+export function SaveGroup(arg1) {
+  return window['go']['main']['App']['SaveGroup'](arg1);
+}
+
+export function SaveReplay() {
+  return window['go']['main']['App']['SaveReplay']();
+}
+
 export function SaveSettings(arg1, arg2) {
   return window['go']['main']['App']['SaveSettings'](arg1, arg2);
 }
@@ -66,22 +226,94 @@ export function SaveWindowSize(arg1, arg2) {
   return window['go']['main']['App']['SaveWindowSize'](arg1, arg2);
 }
 
+export function SeekAudio(arg1, arg2) {
+  return window['go']['main']['App']['SeekAudio'](arg1, arg2);
+}
+
+export function SelectVoicePreset(arg1) {
+  return window['go']['main']['App']['SelectVoicePreset'](arg1);
+}
+
+export function SetAudioBackend(arg1) {
+  return window['go']['main']['App']['SetAudioBackend'](arg1);
+}
+
+export function SetAudioEffects(arg1, arg2) {
+  return window['go']['main']['App']['SetAudioEffects'](arg1, arg2);
+}
+
 export function SetAudioSettings(arg1, arg2, arg3) {
   return window['go']['main']['App']['SetAudioSettings'](arg1, arg2, arg3);
 }
 
+export function SetAudioTransition(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetAudioTransition'](arg1, arg2, arg3);
+}
+
+export function SetImportSettings(arg1) {
+  return window['go']['main']['App']['SetImportSettings'](arg1);
+}
+
+export function SetOutputSettings(arg1) {
+  return window['go']['main']['App']['SetOutputSettings'](arg1);
+}
+
+export function SetPlaybackSettings(arg1) {
+  return window['go']['main']['App']['SetPlaybackSettings'](arg1);
+}
+
+export function SetRecordingSettings(arg1) {
+  return window['go']['main']['App']['SetRecordingSettings'](arg1);
+}
+
+export function SetReplaySettings(arg1) {
+  return window['go']['main']['App']['SetReplaySettings'](arg1);
+}
+
+export function SetTransportHotkeys(arg1) {
+  return window['go']['main']['App']['SetTransportHotkeys'](arg1);
+}
+
+export function SetVoiceSettings(arg1) {
+  return window['go']['main']['App']['SetVoiceSettings'](arg1);
+}
+
 export function Show() {
   return window['go']['main']['App']['Show']();
 }
 
+export function StartClipRecording(arg1) {
+  return window['go']['main']['App']['StartClipRecording'](arg1);
+}
+
+export function StartRecording() {
+  return window['go']['main']['App']['StartRecording']();
+}
+
 export function StartUpdate(arg1) {
   return window['go']['main']['App']['StartUpdate'](arg1);
 }
 
+export function StopAllAudio() {
+  return window['go']['main']['App']['StopAllAudio']();
+}
+
+export function StopClipRecording() {
+  return window['go']['main']['App']['StopClipRecording']();
+}
+
+export function StopRecording() {
+  return window['go']['main']['App']['StopRecording']();
+}
+
 export function ToggleMaximise() {
   return window['go']['main']['App']['ToggleMaximise']();
 }
 
+export function ToggleRecording() {
+  return window['go']['main']['App']['ToggleRecording']();
+}
+
 export function UpdateAudioOrder(arg1) {
   return window['go']['main']['App']['UpdateAudioOrder'](arg1);
 }
@@ -3,6 +3,8 @@ export namespace main {
 	export class AudioDevice {
 	    id: string;
 	    name: string;
+	    is_default: boolean;
+	    formats: AudioFormat[];
 	
 	    static createFrom(source: any = {}) {
 	        return new AudioDevice(source);
@@ -12,6 +14,64 @@ export namespace main {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.id = source["id"];
 	        this.name = source["name"];
+	        this.is_default = source["is_default"];
+	        this.formats = this.convertValues(source["formats"], AudioFormat);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AudioFormat {
+	    format: string;
+	    channels: number;
+	    sample_rate: number;
+	    exclusive: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new AudioFormat(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.format = source["format"];
+	        this.channels = source["channels"];
+	        this.sample_rate = source["sample_rate"];
+	        this.exclusive = source["exclusive"];
+	    }
+	}
+	export class AudioInfo {
+	    format: string;
+	    seconds: number;
+	    bytes: number;
+	    sample_rate: number;
+	    channels: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AudioInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.format = source["format"];
+	        this.seconds = source["seconds"];
+	        this.bytes = source["bytes"];
+	        this.sample_rate = source["sample_rate"];
+	        this.channels = source["channels"];
 	    }
 	}
 	export class AudioItem {
@@ -21,6 +81,11 @@ export namespace main {
 	    hotkey: string;
 	    duration: string;
 	    size: string;
+	    effects?: EffectConfig[];
+	    mode?: string;
+	    crossfade?: number;
+	    category?: string;
+	    stream?: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new AudioItem(source);
@@ -34,6 +99,81 @@ export namespace main {
 	        this.hotkey = source["hotkey"];
 	        this.duration = source["duration"];
 	        this.size = source["size"];
+	        this.effects = this.convertValues(source["effects"], EffectConfig);
+	        this.mode = source["mode"];
+	        this.crossfade = source["crossfade"];
+	        this.category = source["category"];
+	        this.stream = source["stream"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AudioStatus {
+	    backend: string;
+	    main: OutputStatus;
+	    aux: OutputStatus;
+	
+	    static createFrom(source: any = {}) {
+	        return new AudioStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.backend = source["backend"];
+	        this.main = this.convertValues(source["main"], OutputStatus);
+	        this.aux = this.convertValues(source["aux"], OutputStatus);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class BusMeter {
+	    peak: number;
+	    rms: number;
+	    gain_reduction: number;
+	    clips: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BusMeter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.peak = source["peak"];
+	        this.rms = source["rms"];
+	        this.gain_reduction = source["gain_reduction"];
+	        this.clips = source["clips"];
 	    }
 	}
 	export class CheckUpdateResult {
@@ -54,16 +194,73 @@ export namespace main {
 	        this.error = source["error"];
 	    }
 	}
+	export class ClipGroup {
+	    id: string;
+	    name: string;
+	    hotkey: string;
+	    mode: string;
+	    items: GroupItem[];
+	    position: number;
+	    last: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ClipGroup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.hotkey = source["hotkey"];
+	        this.mode = source["mode"];
+	        this.items = this.convertValues(source["items"], GroupItem);
+	        this.position = source["position"];
+	        this.last = source["last"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class Config {
+	    schema_version: number;
 	    audio_list: AudioItem[];
 	    close_action: string;
 	    dont_ask_again: boolean;
 	    volume: number;
 	    main_device: string;
 	    aux_device: string;
+	    main_device_info?: DeviceDescriptor;
+	    aux_device_info?: DeviceDescriptor;
 	    window_width: number;
 	    window_height: number;
 	    sidebar_collapsed: boolean;
+	    output: OutputSettings;
+	    backend: string;
+	    recording: RecordingSettings;
+	    replay: ReplaySettings;
+	    voice: VoiceSettings;
+	    presets: EffectPreset[];
+	    transport: TransportHotkeys;
+	    groups: ClipGroup[];
+	    playback: PlaybackSettings;
+	    library_root: string;
+	    watch_folders: string[];
+	    import: ImportSettings;
 	
 	    static createFrom(source: any = {}) {
 	        return new Config(source);
@@ -71,15 +268,30 @@ export namespace main {
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.schema_version = source["schema_version"];
 	        this.audio_list = this.convertValues(source["audio_list"], AudioItem);
 	        this.close_action = source["close_action"];
 	        this.dont_ask_again = source["dont_ask_again"];
 	        this.volume = source["volume"];
 	        this.main_device = source["main_device"];
 	        this.aux_device = source["aux_device"];
+	        this.main_device_info = this.convertValues(source["main_device_info"], DeviceDescriptor);
+	        this.aux_device_info = this.convertValues(source["aux_device_info"], DeviceDescriptor);
 	        this.window_width = source["window_width"];
 	        this.window_height = source["window_height"];
 	        this.sidebar_collapsed = source["sidebar_collapsed"];
+	        this.output = this.convertValues(source["output"], OutputSettings);
+	        this.backend = source["backend"];
+	        this.recording = this.convertValues(source["recording"], RecordingSettings);
+	        this.replay = this.convertValues(source["replay"], ReplaySettings);
+	        this.voice = this.convertValues(source["voice"], VoiceSettings);
+	        this.presets = this.convertValues(source["presets"], EffectPreset);
+	        this.transport = this.convertValues(source["transport"], TransportHotkeys);
+	        this.groups = this.convertValues(source["groups"], ClipGroup);
+	        this.playback = this.convertValues(source["playback"], PlaybackSettings);
+	        this.library_root = source["library_root"];
+	        this.watch_folders = source["watch_folders"];
+	        this.import = this.convertValues(source["import"], ImportSettings);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -100,6 +312,464 @@ export namespace main {
 		    return a;
 		}
 	}
+	export class ConfigStatus {
+	    load_error: string;
+	    recovered_from: string;
+	    newer_version: number;
+	    save_error: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConfigStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.load_error = source["load_error"];
+	        this.recovered_from = source["recovered_from"];
+	        this.newer_version = source["newer_version"];
+	        this.save_error = source["save_error"];
+	    }
+	}
+	export class DeviceDescriptor {
+	    id: string;
+	    name: string;
+	    channels: number;
+	    backend: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeviceDescriptor(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.channels = source["channels"];
+	        this.backend = source["backend"];
+	    }
+	}
+	export class EffectConfig {
+	    type: string;
+	    params: Record<string, number>;
+	
+	    static createFrom(source: any = {}) {
+	        return new EffectConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.params = source["params"];
+	    }
+	}
+	export class EffectPreset {
+	    id: string;
+	    name: string;
+	    hotkey: string;
+	    effects: EffectConfig[];
+	
+	    static createFrom(source: any = {}) {
+	        return new EffectPreset(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.hotkey = source["hotkey"];
+	        this.effects = this.convertValues(source["effects"], EffectConfig);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class GroupItem {
+	    id: string;
+	    weight: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new GroupItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.weight = source["weight"];
+	    }
+	}
+	export class ImportEntry {
+	    name: string;
+	    path: string;
+	    status: string;
+	    reason: string;
+	    message: string;
+	    id: string;
+	    info?: AudioInfo;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.status = source["status"];
+	        this.reason = source["reason"];
+	        this.message = source["message"];
+	        this.id = source["id"];
+	        this.info = this.convertValues(source["info"], AudioInfo);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ImportReport {
+	    source: string;
+	    added: number;
+	    failed: number;
+	    entries: ImportEntry[];
+	    error: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source = source["source"];
+	        this.added = source["added"];
+	        this.failed = source["failed"];
+	        this.entries = this.convertValues(source["entries"], ImportEntry);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ImportSettings {
+	    max_size_mb: number;
+	    max_seconds: number;
+	    allow_over_limit: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.max_size_mb = source["max_size_mb"];
+	        this.max_seconds = source["max_seconds"];
+	        this.allow_over_limit = source["allow_over_limit"];
+	    }
+	}
+	export class OutputMeters {
+	    main: BusMeter;
+	    aux: BusMeter;
+	
+	    static createFrom(source: any = {}) {
+	        return new OutputMeters(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.main = this.convertValues(source["main"], BusMeter);
+	        this.aux = this.convertValues(source["aux"], BusMeter);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OutputSettings {
+	    sample_rate: number;
+	    channels: number;
+	    period_size: number;
+	    periods: number;
+	    resample_quality: number;
+	    exclusive: boolean;
+	    limiter_ceiling: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OutputSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sample_rate = source["sample_rate"];
+	        this.channels = source["channels"];
+	        this.period_size = source["period_size"];
+	        this.periods = source["periods"];
+	        this.resample_quality = source["resample_quality"];
+	        this.exclusive = source["exclusive"];
+	        this.limiter_ceiling = source["limiter_ceiling"];
+	    }
+	}
+	export class OutputStatus {
+	    active: boolean;
+	    device: string;
+	    sample_rate: number;
+	    channels: number;
+	    format: string;
+	    period_size: number;
+	    exclusive: boolean;
+	    dropped: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OutputStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.active = source["active"];
+	        this.device = source["device"];
+	        this.sample_rate = source["sample_rate"];
+	        this.channels = source["channels"];
+	        this.format = source["format"];
+	        this.period_size = source["period_size"];
+	        this.exclusive = source["exclusive"];
+	        this.dropped = source["dropped"];
+	    }
+	}
+	export class PackImportOptions {
+	    replace: boolean;
+	    hotkeys: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PackImportOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.replace = source["replace"];
+	        this.hotkeys = source["hotkeys"];
+	    }
+	}
+	export class PlaybackSettings {
+	    mode: string;
+	    crossfade: number;
+	    fade_out: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PlaybackSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mode = source["mode"];
+	        this.crossfade = source["crossfade"];
+	        this.fade_out = source["fade_out"];
+	    }
+	}
+	export class PlaybackStatus {
+	    playing: VoiceProgress[];
+	    main: BusMeter;
+	    aux: BusMeter;
+	
+	    static createFrom(source: any = {}) {
+	        return new PlaybackStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.playing = this.convertValues(source["playing"], VoiceProgress);
+	        this.main = this.convertValues(source["main"], BusMeter);
+	        this.aux = this.convertValues(source["aux"], BusMeter);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RecordingSettings {
+	    folder: string;
+	    format: string;
+	    bus: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecordingSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.folder = source["folder"];
+	        this.format = source["format"];
+	        this.bus = source["bus"];
+	    }
+	}
+	export class RecordingStatus {
+	    active: boolean;
+	    path: string;
+	    seconds: number;
+	    dropped: number;
+	    error: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecordingStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.active = source["active"];
+	        this.path = source["path"];
+	        this.seconds = source["seconds"];
+	        this.dropped = source["dropped"];
+	        this.error = source["error"];
+	    }
+	}
+	export class ReplaySettings {
+	    enabled: boolean;
+	    source: string;
+	    device: string;
+	    seconds: number;
+	    hotkey: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReplaySettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.source = source["source"];
+	        this.device = source["device"];
+	        this.seconds = source["seconds"];
+	        this.hotkey = source["hotkey"];
+	    }
+	}
+	export class TransportHotkeys {
+	    pause_resume: string;
+	    seek_back: string;
+	    seek_forward: string;
+	    seek_step: number;
+	    stop_all: string;
+	    panic: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TransportHotkeys(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pause_resume = source["pause_resume"];
+	        this.seek_back = source["seek_back"];
+	        this.seek_forward = source["seek_forward"];
+	        this.seek_step = source["seek_step"];
+	        this.stop_all = source["stop_all"];
+	        this.panic = source["panic"];
+	    }
+	}
+	export class VoiceProgress {
+	    id: string;
+	    bus: string;
+	    position: number;
+	    duration: number;
+	    paused: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new VoiceProgress(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.bus = source["bus"];
+	        this.position = source["position"];
+	        this.duration = source["duration"];
+	        this.paused = source["paused"];
+	    }
+	}
+	export class VoiceSettings {
+	    enabled: boolean;
+	    device: string;
+	    preset: string;
+	    clip_preset: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new VoiceSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.device = source["device"];
+	        this.preset = source["preset"];
+	        this.clip_preset = source["clip_preset"];
+	    }
+	}
 
 }
 
@@ -0,0 +1,585 @@
+package main
+
+import (
+	"math"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// EffectConfig is one stage of an effect chain. Params depend on Type; any
+// that are missing take the value from effectDefaults.
+type EffectConfig struct {
+	Type   string             `json:"type"`
+	Params map[string]float64 `json:"params"`
+}
+
+// EffectPreset is a named effect chain that can be put on the mic or on clips
+type EffectPreset struct {
+	ID      string         `json:"id"`
+	Name    string         `json:"name"`
+	Hotkey  string         `json:"hotkey"`
+	Effects []EffectConfig `json:"effects"`
+}
+
+// effectDefaults lists every effect type and its parameters
+var effectDefaults = map[string]map[string]float64{
+	"pitch":      {"semitones": 0},                            // -12 to 12
+	"formant":    {"shift": 1},                                // 0.5 to 2, >1 sounds smaller
+	"robot":      {"frequency": 50, "mix": 1},                 // Ring modulator, Hz
+	"reverb":     {"room": 0.7, "damping": 0.5, "mix": 0.3},   // 0 to 1
+	"echo":       {"delay": 250, "feedback": 0.4, "mix": 0.5}, // ms, 0 to 0.95, 0 to 1
+	"distortion": {"drive": 12, "mix": 1},                     // dB
+	"eq":         {"low": 0, "mid": 0, "high": 0},             // dB at 200 Hz, 1 kHz, 4 kHz
+	"lowpass":    {"frequency": 3000},                         // Hz
+	"highpass":   {"frequency": 300},                          // Hz
+	"speed":      {"rate": 1, "keep_pitch": 0},                // 0.25 to 4, keep_pitch 0 or 1
+	"reverse":    {},                                          // Whole clip, wherever it is in the chain (clips only)
+}
+
+func (e EffectConfig) param(name string) float64 {
+	if v, ok := e.Params[name]; ok {
+		return v
+	}
+	return effectDefaults[e.Type][name]
+}
+
+// buildEffectChain wraps s in each effect in order. Unknown types are skipped.
+// Every effect processes in place and allocates only here, so the chain is
+// safe to run on the audio thread.
+func buildEffectChain(s beep.Streamer, chain []EffectConfig, rate beep.SampleRate, quality int) beep.Streamer {
+	for _, e := range chain {
+		switch e.Type {
+		case "pitch":
+			s = newPitchShift(s, rate, e.param("semitones"))
+		case "formant":
+			s = newFormantShift(s, rate, e.param("shift"))
+		case "robot":
+			s = newRingMod(s, rate, e.param("frequency"), e.param("mix"))
+		case "reverb":
+			s = newReverb(s, rate, e.param("room"), e.param("damping"), e.param("mix"))
+		case "echo":
+			s = newEcho(s, rate, e.param("delay"), e.param("feedback"), e.param("mix"))
+		case "distortion":
+			s = newDistortion(s, e.param("drive"), e.param("mix"))
+		case "eq":
+			s = newEQ(s, rate, e.param("low"), e.param("mid"), e.param("high"))
+		case "lowpass", "highpass":
+			s = &filter{Streamer: s, bands: []biquad{
+				newBiquad(e.Type, rate, clamp(e.param("frequency"), 20, float64(rate)/2-1), 0, math.Sqrt2/2),
+			}}
+		case "speed":
+			s = newSpeed(s, rate, quality, e.param("rate"), e.param("keep_pitch") != 0)
+		}
+	}
+	return s
+}
+
+func hasEffect(chain []EffectConfig, typ string) bool {
+	for _, e := range chain {
+		if e.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func clamp(x, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, x))
+}
+
+// pitchShift is a delay-line pitch shifter: two taps sweep through a short
+// delay at the rate that gives the wanted ratio, each faded out as it wraps.
+type pitchShift struct {
+	beep.Streamer
+	buf    [][2]float64
+	pos    int
+	phase  float64 // 0-1 through the window
+	step   float64
+	window float64 // Samples
+}
+
+const pitchWindow = 0.04 // Seconds
+
+func newPitchShift(s beep.Streamer, rate beep.SampleRate, semitones float64) *pitchShift {
+	ratio := math.Pow(2, clamp(semitones, -24, 24)/12)
+	window := pitchWindow * float64(rate)
+	return &pitchShift{
+		Streamer: s,
+		buf:      make([][2]float64, int(window)+4),
+		step:     (1 - ratio) / window,
+		window:   window,
+	}
+}
+
+func (p *pitchShift) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = p.Streamer.Stream(samples)
+	for i := range samples[:n] {
+		p.buf[p.pos] = samples[i]
+
+		var out [2]float64
+		for _, tap := range [2]float64{p.phase, math.Mod(p.phase+0.5, 1)} {
+			gain := 0.5 - 0.5*math.Cos(2*math.Pi*tap)
+			s := readDelay(p.buf, p.pos, tap*p.window)
+			out[0] += gain * s[0]
+			out[1] += gain * s[1]
+		}
+		samples[i] = out
+
+		p.phase += p.step
+		p.phase -= math.Floor(p.phase)
+		p.pos = (p.pos + 1) % len(p.buf)
+	}
+	return n, ok
+}
+
+// readDelay reads buf delay samples behind pos with linear interpolation
+func readDelay(buf [][2]float64, pos int, delay float64) [2]float64 {
+	d := int(delay)
+	frac := delay - float64(d)
+	i := ((pos-d)%len(buf) + len(buf)) % len(buf)
+	j := (i - 1 + len(buf)) % len(buf)
+	return [2]float64{
+		buf[i][0] + (buf[j][0]-buf[i][0])*frac,
+		buf[i][1] + (buf[j][1]-buf[i][1])*frac,
+	}
+}
+
+// formantShift moves the spectral envelope without changing the pitch. Each
+// pitch period is used as a grain: grains are resampled by the shift factor,
+// which moves the formants, but laid down at the original period spacing,
+// which keeps the pitch. Consecutive grains are cross-faded.
+type formantShift struct {
+	beep.Streamer
+	shift float64
+	hist  [][2]float64 // Input history, indexed by absolute sample & mask
+	mask  int64
+	n     int64 // Input samples consumed
+	delay int64 // How far output lags input, enough to read a stretched grain
+
+	epoch, next int64 // Current and next grain centres
+	period      int64 // Latest pitch period estimate
+
+	// Pitch detection runs on a decimated mono copy of the input
+	dec            []float64
+	decPos         int
+	decAcc         float64
+	minLag, maxLag int // In decimated samples
+	fallback       int64
+}
+
+const (
+	formantDecimate  = 4
+	formantMinPitch  = 70  // Hz
+	formantMaxPitch  = 400 // Hz
+	formantEstimate  = 1024
+	formantVoicedMin = 0.5
+)
+
+func newFormantShift(s beep.Streamer, rate beep.SampleRate, shift float64) *formantShift {
+	shift = clamp(shift, 0.5, 2)
+	maxPeriod := int64(rate) / formantMinPitch
+	delay := int64(math.Ceil(float64(maxPeriod)*shift)) + 2
+
+	size := int64(1)
+	for size < 2*(delay+maxPeriod*2) {
+		size <<= 1
+	}
+
+	decRate := int(rate) / formantDecimate
+	maxLag := decRate / formantMinPitch
+	fallback := int64(rate) / 150 // Used for unvoiced sound
+	return &formantShift{
+		Streamer: s,
+		shift:    shift,
+		hist:     make([][2]float64, size),
+		mask:     size - 1,
+		delay:    delay,
+		period:   fallback,
+		next:     -delay,
+		dec:      make([]float64, 3*maxLag),
+		minLag:   decRate / formantMaxPitch,
+		maxLag:   maxLag,
+		fallback: fallback,
+	}
+}
+
+func (f *formantShift) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = f.Streamer.Stream(samples)
+	for i := range samples[:n] {
+		f.hist[f.n&f.mask] = samples[i]
+		f.n++
+		f.track(samples[i])
+
+		t := f.n - f.delay
+		for t >= f.next {
+			f.epoch = f.next
+			f.next = f.epoch + f.period
+		}
+
+		// Cross-fade from the grain around epoch to the one around next
+		w := 0.5 - 0.5*math.Cos(math.Pi*float64(t-f.epoch)/float64(f.next-f.epoch))
+		a := f.read(float64(f.epoch) + float64(t-f.epoch)*f.shift)
+		b := f.read(float64(f.next) + float64(t-f.next)*f.shift)
+		samples[i] = [2]float64{
+			a[0]*(1-w) + b[0]*w,
+			a[1]*(1-w) + b[1]*w,
+		}
+	}
+	return n, ok
+}
+
+// read interpolates the input at an absolute (fractional) index
+func (f *formantShift) read(at float64) [2]float64 {
+	i := int64(math.Floor(at))
+	frac := at - float64(i)
+	x, y := f.hist[i&f.mask], f.hist[(i+1)&f.mask]
+	return [2]float64{x[0] + (y[0]-x[0])*frac, x[1] + (y[1]-x[1])*frac}
+}
+
+// track feeds the pitch detector and re-estimates the period every so often
+func (f *formantShift) track(s [2]float64) {
+	f.decAcc += s[0] + s[1]
+	if f.n%formantDecimate != 0 {
+		return
+	}
+	copy(f.dec, f.dec[1:])
+	f.dec[len(f.dec)-1] = f.decAcc / (2 * formantDecimate)
+	f.decAcc = 0
+	f.decPos++
+
+	if f.decPos*formantDecimate < formantEstimate {
+		return
+	}
+	f.decPos = 0
+
+	// Normalised autocorrelation over the pitch range
+	best, bestLag := 0.0, 0
+	for lag := f.minLag; lag <= f.maxLag; lag++ {
+		var xy, xx, yy float64
+		for j := 0; j+lag < len(f.dec); j++ {
+			x, y := f.dec[j], f.dec[j+lag]
+			xy += x * y
+			xx += x * x
+			yy += y * y
+		}
+		if xx == 0 || yy == 0 {
+			continue
+		}
+		if r := xy / math.Sqrt(xx*yy); r > best {
+			best, bestLag = r, lag
+		}
+	}
+	if best >= formantVoicedMin {
+		f.period = int64(bestLag * formantDecimate)
+	} else {
+		f.period = f.fallback
+	}
+}
+
+// ringMod multiplies the signal by a sine wave, the classic "robot" voice
+type ringMod struct {
+	beep.Streamer
+	phase, step, mix float64
+}
+
+func newRingMod(s beep.Streamer, rate beep.SampleRate, frequency, mix float64) *ringMod {
+	return &ringMod{
+		Streamer: s,
+		step:     2 * math.Pi * clamp(frequency, 1, 5000) / float64(rate),
+		mix:      clamp(mix, 0, 1),
+	}
+}
+
+func (r *ringMod) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = r.Streamer.Stream(samples)
+	for i := range samples[:n] {
+		g := 1 - r.mix + r.mix*math.Sin(r.phase)
+		samples[i][0] *= g
+		samples[i][1] *= g
+		r.phase += r.step
+		if r.phase > 2*math.Pi {
+			r.phase -= 2 * math.Pi
+		}
+	}
+	return n, ok
+}
+
+// echo is a feedback delay
+type echo struct {
+	beep.Streamer
+	buf           [][2]float64
+	pos           int
+	feedback, mix float64
+}
+
+func newEcho(s beep.Streamer, rate beep.SampleRate, delayMs, feedback, mix float64) *echo {
+	n := max(1, int(clamp(delayMs, 1, 2000)/1000*float64(rate)))
+	return &echo{
+		Streamer: s,
+		buf:      make([][2]float64, n),
+		feedback: clamp(feedback, 0, 0.95),
+		mix:      clamp(mix, 0, 1),
+	}
+}
+
+func (e *echo) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = e.Streamer.Stream(samples)
+	for i := range samples[:n] {
+		d := e.buf[e.pos]
+		for c := 0; c < 2; c++ {
+			x := samples[i][c]
+			e.buf[e.pos][c] = x + d[c]*e.feedback
+			samples[i][c] = x + d[c]*e.mix
+		}
+		e.pos = (e.pos + 1) % len(e.buf)
+	}
+	return n, ok
+}
+
+// distortion is a tanh soft clipper
+type distortion struct {
+	beep.Streamer
+	gain, mix float64
+}
+
+func newDistortion(s beep.Streamer, driveDB, mix float64) *distortion {
+	return &distortion{
+		Streamer: s,
+		gain:     math.Pow(10, clamp(driveDB, 0, 48)/20),
+		mix:      clamp(mix, 0, 1),
+	}
+}
+
+func (d *distortion) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = d.Streamer.Stream(samples)
+	for i := range samples[:n] {
+		for c := 0; c < 2; c++ {
+			x := samples[i][c]
+			samples[i][c] = x*(1-d.mix) + math.Tanh(x*d.gain)*d.mix
+		}
+	}
+	return n, ok
+}
+
+// biquad is a stereo RBJ filter (transposed direct form II)
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z                  [2][2]float64
+}
+
+func (q *biquad) process(c int, x float64) float64 {
+	y := q.b0*x + q.z[c][0]
+	q.z[c][0] = q.b1*x - q.a1*y + q.z[c][1]
+	q.z[c][1] = q.b2*x - q.a2*y
+	return y
+}
+
+// newBiquad builds a "lowpass", "highpass", "lowshelf", "peak" or "highshelf"
+// filter (shelf slope 1; gain only applies to the last three)
+func newBiquad(kind string, rate beep.SampleRate, freq, gainDB, q float64) biquad {
+	A := math.Pow(10, gainDB/40)
+	w := 2 * math.Pi * freq / float64(rate)
+	cos, sin := math.Cos(w), math.Sin(w)
+
+	var b0, b1, b2, a0, a1, a2 float64
+	switch kind {
+	case "lowpass":
+		alpha := sin / (2 * q)
+		b0, b1, b2 = (1-cos)/2, 1-cos, (1-cos)/2
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case "highpass":
+		alpha := sin / (2 * q)
+		b0, b1, b2 = (1+cos)/2, -(1 + cos), (1+cos)/2
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case "peak":
+		alpha := sin / (2 * q)
+		b0, b1, b2 = 1+alpha*A, -2*cos, 1-alpha*A
+		a0, a1, a2 = 1+alpha/A, -2*cos, 1-alpha/A
+	case "lowshelf":
+		alpha := sin / 2 * math.Sqrt2
+		sq := 2 * math.Sqrt(A) * alpha
+		b0, b1, b2 = A*((A+1)-(A-1)*cos+sq), 2*A*((A-1)-(A+1)*cos), A*((A+1)-(A-1)*cos-sq)
+		a0, a1, a2 = (A+1)+(A-1)*cos+sq, -2*((A-1)+(A+1)*cos), (A+1)+(A-1)*cos-sq
+	case "highshelf":
+		alpha := sin / 2 * math.Sqrt2
+		sq := 2 * math.Sqrt(A) * alpha
+		b0, b1, b2 = A*((A+1)+(A-1)*cos+sq), -2*A*((A-1)+(A+1)*cos), A*((A+1)+(A-1)*cos-sq)
+		a0, a1, a2 = (A+1)-(A-1)*cos+sq, 2*((A-1)-(A+1)*cos), (A+1)-(A-1)*cos-sq
+	}
+	return biquad{b0: b0 / a0, b1: b1 / a0, b2: b2 / a0, a1: a1 / a0, a2: a2 / a0}
+}
+
+// filter runs the signal through biquads in series
+type filter struct {
+	beep.Streamer
+	bands []biquad
+}
+
+// newEQ is a three-band equaliser
+func newEQ(s beep.Streamer, rate beep.SampleRate, low, mid, high float64) *filter {
+	return &filter{
+		Streamer: s,
+		bands: []biquad{
+			newBiquad("lowshelf", rate, 200, clamp(low, -24, 24), 0),
+			newBiquad("peak", rate, 1000, clamp(mid, -24, 24), 1),
+			newBiquad("highshelf", rate, 4000, clamp(high, -24, 24), 0),
+		},
+	}
+}
+
+func (f *filter) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = f.Streamer.Stream(samples)
+	for i := range samples[:n] {
+		for c := 0; c < 2; c++ {
+			x := samples[i][c]
+			for b := range f.bands {
+				x = f.bands[b].process(c, x)
+			}
+			samples[i][c] = x
+		}
+	}
+	return n, ok
+}
+
+// reverb is a Freeverb-style reverberator: parallel damped combs into series allpasses
+type reverb struct {
+	beep.Streamer
+	combs     [2][8]comb
+	allpasses [2][4]allpass
+	mix       float64
+}
+
+var (
+	reverbCombTunings    = [8]int{1116, 1188, 1277, 1356, 1422, 1491, 1557, 1617}
+	reverbAllpassTunings = [4]int{556, 441, 341, 225}
+)
+
+const (
+	reverbSpread   = 23 // Right channel offset, for stereo width
+	reverbInGain   = 0.015
+	reverbWetScale = 3
+)
+
+type comb struct {
+	buf                   []float64
+	pos                   int
+	store, feedback, damp float64
+}
+
+func (c *comb) process(x float64) float64 {
+	y := c.buf[c.pos]
+	c.store = y*(1-c.damp) + c.store*c.damp
+	c.buf[c.pos] = x + c.store*c.feedback
+	c.pos = (c.pos + 1) % len(c.buf)
+	return y
+}
+
+type allpass struct {
+	buf []float64
+	pos int
+}
+
+func (a *allpass) process(x float64) float64 {
+	b := a.buf[a.pos]
+	a.buf[a.pos] = x + b*0.5
+	a.pos = (a.pos + 1) % len(a.buf)
+	return b - x
+}
+
+func newReverb(s beep.Streamer, rate beep.SampleRate, room, damping, mix float64) *reverb {
+	// Tunings are for 44.1 kHz
+	scale := float64(rate) / 44100
+	r := &reverb{Streamer: s, mix: clamp(mix, 0, 1)}
+	for ch := 0; ch < 2; ch++ {
+		for i, t := range reverbCombTunings {
+			r.combs[ch][i] = comb{
+				buf:      make([]float64, max(1, int(float64(t+ch*reverbSpread)*scale))),
+				feedback: clamp(room, 0, 1)*0.28 + 0.7,
+				damp:     clamp(damping, 0, 1) * 0.4,
+			}
+		}
+		for i, t := range reverbAllpassTunings {
+			r.allpasses[ch][i] = allpass{buf: make([]float64, max(1, int(float64(t+ch*reverbSpread)*scale)))}
+		}
+	}
+	return r
+}
+
+func (r *reverb) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = r.Streamer.Stream(samples)
+	for i := range samples[:n] {
+		in := (samples[i][0] + samples[i][1]) * reverbInGain
+		for ch := 0; ch < 2; ch++ {
+			var wet float64
+			for c := range r.combs[ch] {
+				wet += r.combs[ch][c].process(in)
+			}
+			for a := range r.allpasses[ch] {
+				wet = r.allpasses[ch][a].process(wet)
+			}
+			samples[i][ch] = samples[i][ch]*(1-r.mix) + wet*reverbWetScale*r.mix
+		}
+	}
+	return n, ok
+}
+
+// newSpeed plays faster or slower by resampling with the output's quality,
+// which also shifts the pitch unless keepPitch shifts it back
+func newSpeed(s beep.Streamer, rate beep.SampleRate, quality int, speed float64, keepPitch bool) beep.Streamer {
+	speed = clampSpeed(speed)
+	if speed == 1 {
+		return s
+	}
+	s = beep.ResampleRatio(quality, speed, s)
+	if keepPitch {
+		s = newPitchShift(s, rate, -12*math.Log2(speed))
+	}
+	return s
+}
+
+func clampSpeed(speed float64) float64 {
+	return clamp(speed, 0.25, 4)
+}
+
+// chainSpeed is how many times faster than recorded a chain plays a clip
+func chainSpeed(chain []EffectConfig) float64 {
+	speed := 1.0
+	for _, e := range chain {
+		if e.Type == "speed" {
+			speed *= clampSpeed(e.param("rate"))
+		}
+	}
+	return speed
+}
+
+// reverseBuffer returns a copy of buffer that plays backwards
+func reverseBuffer(buffer *beep.Buffer) *beep.Buffer {
+	samples := make([][2]float64, buffer.Len())
+	buffer.Streamer(0, buffer.Len()).Stream(samples)
+	for i, j := 0, len(samples)-1; i < j; i, j = i+1, j-1 {
+		samples[i], samples[j] = samples[j], samples[i]
+	}
+
+	reversed := beep.NewBuffer(buffer.Format())
+	reversed.Append(&sliceStreamer{samples: samples})
+	return reversed
+}
+
+// sliceStreamer plays samples held in memory
+type sliceStreamer struct {
+	samples [][2]float64
+	pos     int
+}
+
+func (s *sliceStreamer) Stream(samples [][2]float64) (n int, ok bool) {
+	if s.pos >= len(s.samples) {
+		return 0, false
+	}
+	n = copy(samples, s.samples[s.pos:])
+	s.pos += n
+	return n, true
+}
+
+func (s *sliceStreamer) Err() error {
+	return nil
+}
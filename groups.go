@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ClipGroup plays one of several clips from a single hotkey
+type ClipGroup struct {
+	ID       string      `json:"id"`
+	Name     string      `json:"name"`
+	Hotkey   string      `json:"hotkey"`
+	Mode     string      `json:"mode"` // "random", "weighted", "round_robin" or "playlist"
+	Items    []GroupItem `json:"items"`
+	Position int         `json:"position"` // Next item for round_robin and playlist
+	Last     string      `json:"last"`     // Last item played, so random doesn't repeat it
+}
+
+// GroupItem references an AudioItem
+type GroupItem struct {
+	ID     string  `json:"id"`
+	Weight float64 `json:"weight"` // Only used by "weighted"; <= 0 counts as 1
+}
+
+// Group modes
+const (
+	groupRandom     = "random"      // Uniform, never the same clip twice in a row
+	groupWeighted   = "weighted"    // By weight, repeats allowed
+	groupRoundRobin = "round_robin" // In order, wrapping around
+	groupPlaylist   = "playlist"    // In order, one press at a time; emits "group-finished" at the end
+)
+
+// pickGroupItem chooses the next clip ID and advances the group's state.
+// Items that no longer exist are skipped. Caller holds a.mu.
+func (a *App) pickGroupItem(g *ClipGroup) string {
+	exists := make(map[string]bool, len(a.Config.AudioList))
+	for _, item := range a.Config.AudioList {
+		exists[item.ID] = true
+	}
+	var items []GroupItem
+	for _, it := range g.Items {
+		if exists[it.ID] {
+			items = append(items, it)
+		}
+	}
+	if len(items) == 0 {
+		return ""
+	}
+
+	var id string
+	switch g.Mode {
+	case groupWeighted:
+		var total float64
+		for _, it := range items {
+			total += groupWeight(it)
+		}
+		r := rand.Float64() * total
+		id = items[len(items)-1].ID
+		for _, it := range items {
+			if r -= groupWeight(it); r < 0 {
+				id = it.ID
+				break
+			}
+		}
+	case groupRoundRobin, groupPlaylist:
+		if g.Position < 0 || g.Position >= len(items) {
+			g.Position = 0
+		}
+		id = items[g.Position].ID
+		g.Position++
+		if g.Position >= len(items) {
+			g.Position = 0
+			if g.Mode == groupPlaylist {
+				runtime.EventsEmit(a.ctx, "group-finished", g.ID)
+			}
+		}
+	default: // groupRandom
+		var candidates []GroupItem
+		for _, it := range items {
+			if it.ID != g.Last {
+				candidates = append(candidates, it)
+			}
+		}
+		if len(candidates) == 0 {
+			candidates = items // Only one distinct clip
+		}
+		id = candidates[rand.IntN(len(candidates))].ID
+	}
+
+	g.Last = id
+	return id
+}
+
+func groupWeight(it GroupItem) float64 {
+	if it.Weight <= 0 {
+		return 1
+	}
+	return it.Weight
+}
+
+// PlayGroup plays the group's next clip
+func (a *App) PlayGroup(id string) {
+	a.mu.Lock()
+	var itemID string
+	for _, g := range a.Config.Groups {
+		if g.ID == id {
+			itemID = a.pickGroupItem(g)
+			break
+		}
+	}
+	if itemID != "" {
+		a.saveConfig() // Keep the position across restarts
+	}
+	a.mu.Unlock()
+
+	if itemID != "" {
+		a.playAudio(itemID)
+	}
+}
+
+// ResetGroup rewinds a round-robin group or playlist to its first clip
+func (a *App) ResetGroup(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, g := range a.Config.Groups {
+		if g.ID == id {
+			g.Position = 0
+			g.Last = ""
+			break
+		}
+	}
+	a.saveConfig()
+}
+
+func (a *App) GetGroups() []ClipGroup {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	groups := make([]ClipGroup, len(a.Config.Groups))
+	for i, g := range a.Config.Groups {
+		groups[i] = *g
+	}
+	return groups
+}
+
+// SaveGroup adds a group (empty ID) or replaces the one with the same ID
+func (a *App) SaveGroup(group ClipGroup) ClipGroup {
+	if strings.EqualFold(group.Hotkey, "esc") || strings.EqualFold(group.Hotkey, "escape") {
+		group.Hotkey = ""
+	}
+	switch group.Mode {
+	case groupRandom, groupWeighted, groupRoundRobin, groupPlaylist:
+	default:
+		group.Mode = groupRandom
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if group.ID == "" {
+		group.ID = fmt.Sprintf("g%d", time.Now().UnixNano())
+	}
+	stored := group
+	for i, g := range a.Config.Groups {
+		if g.ID == group.ID {
+			a.Config.Groups[i] = &stored
+			a.saveConfig()
+			return group
+		}
+	}
+	a.Config.Groups = append(a.Config.Groups, &stored)
+	a.saveConfig()
+	return group
+}
+
+func (a *App) DeleteGroup(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i, g := range a.Config.Groups {
+		if g.ID == id {
+			a.Config.Groups = append(a.Config.Groups[:i], a.Config.Groups[i+1:]...)
+			break
+		}
+	}
+	a.saveConfig()
+}
+
+// removeFromGroups drops a deleted clip from every group. Caller holds a.mu.
+func (a *App) removeFromGroups(itemID string) {
+	for _, g := range a.Config.Groups {
+		kept := g.Items[:0]
+		for _, it := range g.Items {
+			if it.ID != itemID {
+				kept = append(kept, it)
+			}
+		}
+		g.Items = kept
+	}
+}
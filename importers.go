@@ -0,0 +1,431 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// ImportEntry is the outcome for one imported file
+type ImportEntry struct {
+	Name    string     `json:"name"`
+	Path    string     `json:"path"`
+	Status  string     `json:"status"`         // "added", "warning" (added, but see Message) or "failed"
+	Reason  string     `json:"reason"`         // Code for why it failed or was flagged (reasonTooLarge...), "" if neither
+	Message string     `json:"message"`        // Why it failed, or what was left out
+	ID      string     `json:"id"`             // Of the new item, "" if it failed
+	Info    *AudioInfo `json:"info,omitempty"` // What was measured, if the file could be read
+}
+
+// ImportReport lists what happened to every file of an import
+type ImportReport struct {
+	Source  string        `json:"source"` // The list or folder that was read, "" for loose files
+	Added   int           `json:"added"`
+	Failed  int           `json:"failed"`
+	Entries []ImportEntry `json:"entries"`
+	Error   string        `json:"error"` // The list or folder itself couldn't be read
+}
+
+// add records an entry and counts it
+func (r *ImportReport) add(e ImportEntry) {
+	if e.Status == importFailed {
+		r.Failed++
+	} else {
+		r.Added++
+	}
+	r.Entries = append(r.Entries, e)
+}
+
+// note records a warning about something that isn't a file, such as a
+// group or preset from a pack; it isn't counted
+func (r *ImportReport) note(name, reason, message string) {
+	r.Entries = append(r.Entries, ImportEntry{Name: name, Status: importWarning, Reason: reason, Message: message})
+}
+
+// Reason codes of sound list entries
+const (
+	reasonMissingPath   = "missing_path"
+	reasonUnknownHotkey = "unknown_hotkey"
+	reasonHotkeyTaken   = "hotkey_taken"
+)
+
+const (
+	importAdded   = "added"
+	importWarning = "warning"
+	importFailed  = "failed"
+)
+
+// soundListEntry is one sound read from another app's list
+type soundListEntry struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Hotkey string `json:"hotkey"`
+}
+
+// ImportSoundList asks for another soundboard's saved list (Soundpad .spl,
+// or CSV/JSON with name, path and hotkey) and imports its sounds. Returns
+// nil if cancelled.
+func (a *App) ImportSoundList() *ImportReport {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Sound List",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Sound Lists", Pattern: "*.spl;*.csv;*.json"},
+		},
+	})
+	if err != nil || path == "" {
+		return nil
+	}
+	report := a.importSoundList(path)
+	return &report
+}
+
+func (a *App) importSoundList(path string) ImportReport {
+	report := ImportReport{Source: filepath.Base(path), Entries: []ImportEntry{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	var entries []soundListEntry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".spl":
+		entries, err = parseSoundpadList(data)
+	case ".csv":
+		entries, err = parseCSVSoundList(data)
+	case ".json":
+		entries, err = parseJSONSoundList(data)
+	default:
+		err = errors.New("不支持的列表格式")
+	}
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	// Relative paths are relative to the list
+	base := filepath.Dir(path)
+	a.mu.Lock()
+	root := a.libraryRoot()
+	a.mu.Unlock()
+	for _, e := range entries {
+		file := expandPathVars(e.Path, root)
+		if file != "" && !filepath.IsAbs(file) {
+			file = filepath.Join(base, file)
+		}
+		report.add(a.importSoundListEntry(e, file))
+	}
+
+	if report.Added > 0 {
+		a.mu.Lock()
+		a.saveConfig()
+		a.mu.Unlock()
+		runtime.EventsEmit(a.ctx, "audios-changed")
+	}
+	return report
+}
+
+// importSoundListEntry validates and adds one sound, keeping its name and
+// (if it can be mapped and isn't taken) its hotkey
+func (a *App) importSoundListEntry(e soundListEntry, file string) ImportEntry {
+	if file == "" {
+		return ImportEntry{Name: e.Name, Status: importFailed, Reason: reasonMissingPath, Message: "缺少文件路径"}
+	}
+
+	item, entry := a.importAudioFile(file)
+	if item == nil {
+		if e.Name != "" {
+			entry.Name = e.Name
+		}
+		return entry
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if e.Name != "" {
+		item.Name = e.Name
+	}
+	entry.Name = item.Name
+	if e.Hotkey == "" {
+		return entry
+	}
+	hotkey, ok := mapHotkey(e.Hotkey)
+	if !ok {
+		entry.warn(reasonUnknownHotkey, fmt.Sprintf("无法识别的热键 %s，未绑定", e.Hotkey))
+		return entry
+	}
+	if _, taken := a.hotkeyOwners()[strings.ToLower(hotkey)]; taken {
+		entry.warn(reasonHotkeyTaken, fmt.Sprintf("热键 %s 已被占用，未绑定", hotkey))
+		return entry
+	}
+	item.Hotkey = hotkey
+	return entry
+}
+
+// warn flags an added entry, keeping any earlier warning's reason and message
+func (e *ImportEntry) warn(reason, message string) {
+	e.Status = importWarning
+	if e.Reason == "" {
+		e.Reason = reason
+	}
+	if e.Message != "" {
+		message = e.Message + "；" + message
+	}
+	e.Message = message
+}
+
+// mapHotkey turns another app's hotkey notation into ours ("Ctrl+Alt+Shift+K").
+// It understands "+", "-" or space separated names with the usual aliases
+// ("Control", "LAlt", "Strg", "Return"...) and AutoHotkey's "^!+#" prefixes.
+// Escape is refused: it is what clears a hotkey here.
+func mapHotkey(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", false
+	}
+
+	var parts []string
+	if i := strings.IndexFunc(s, func(r rune) bool { return !strings.ContainsRune("^!+#", r) }); i > 0 && !strings.ContainsAny(s[i:], "+ ") {
+		// AutoHotkey: modifier symbols, then the key
+		for _, r := range s[:i] {
+			parts = append(parts, map[rune]string{'^': "Ctrl", '!': "Alt", '+': "Shift", '#': "Win"}[r])
+		}
+		parts = append(parts, s[i:])
+	} else {
+		parts = splitHotkey(s)
+	}
+
+	var ctrl, alt, shift, win bool
+	key := ""
+	for _, p := range parts {
+		switch modifierName(p) {
+		case "Ctrl":
+			ctrl = true
+		case "Alt":
+			alt = true
+		case "Shift":
+			shift = true
+		case "Win":
+			win = true
+		default:
+			if key != "" {
+				return "", false // Two non-modifier keys
+			}
+			key = canonicalKeyName(p)
+		}
+	}
+	if key == "" || key == "Escape" || keyNameToVKCode(key) == 0 {
+		return "", false
+	}
+
+	var keys []string
+	for _, m := range []struct {
+		on   bool
+		name string
+	}{{ctrl, "Ctrl"}, {alt, "Alt"}, {shift, "Shift"}, {win, "Win"}} {
+		if m.on {
+			keys = append(keys, m.name)
+		}
+	}
+	return strings.Join(append(keys, key), "+"), true
+}
+
+// splitHotkey splits a hotkey at "+" and spaces, and at a "-" that follows a
+// modifier, so "Ctrl-Alt-K" works while "Ctrl+-" and "Ctrl--" are Ctrl and
+// the minus key, and key names with a dash in them stay whole
+func splitHotkey(s string) []string {
+	var parts []string
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == '+' || r == ' ' }) {
+		for {
+			i := strings.IndexByte(f[1:], '-') + 1 // A leading dash is the key itself
+			if i <= 0 || i == len(f)-1 || modifierName(f[:i]) == "" {
+				break
+			}
+			parts = append(parts, f[:i])
+			f = f[i+1:]
+		}
+		parts = append(parts, f)
+	}
+	return parts
+}
+
+// modifierName returns the modifier a name stands for ("Ctrl", "Alt",
+// "Shift" or "Win"), or "" if it isn't one
+func modifierName(name string) string {
+	switch strings.ToUpper(name) {
+	case "CTRL", "CONTROL", "CTL", "STRG", "LCTRL", "RCTRL", "LCONTROL", "RCONTROL", "^":
+		return "Ctrl"
+	case "ALT", "MENU", "LALT", "RALT", "ALTGR", "OPTION", "!":
+		return "Alt"
+	case "SHIFT", "LSHIFT", "RSHIFT", "UMSCHALT":
+		return "Shift"
+	case "WIN", "WINDOWS", "LWIN", "RWIN", "META", "SUPER", "CMD", "#":
+		return "Win"
+	}
+	return ""
+}
+
+// canonicalKeyName spells a key the way the hotkey recorder does
+func canonicalKeyName(name string) string {
+	switch upper := strings.ToUpper(name); upper {
+	case "RETURN", "ENTER":
+		return "Enter"
+	case "ESC", "ESCAPE":
+		return "Escape"
+	case "SPACE", "SPACEBAR":
+		return "Space"
+	case "TAB":
+		return "Tab"
+	case "BACK", "BACKSPACE", "BKSP":
+		return "Backspace"
+	case "MINUS", "DASH", "HYPHEN":
+		return "-"
+	default:
+		return upper // Letters, digits and F1-F12
+	}
+}
+
+// parseSoundpadList reads a Soundpad sound list: <Sound url="..." title="..."/>
+// elements, possibly nested in categories
+func parseSoundpadList(data []byte) ([]soundListEntry, error) {
+	type node struct {
+		XMLName xml.Name
+		Attrs   []xml.Attr `xml:",any,attr"`
+		Nodes   []node     `xml:",any"`
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		switch strings.ToLower(label) {
+		case "gbk", "gb2312", "gb18030":
+			return simplifiedchinese.GB18030.NewDecoder().Reader(input), nil
+		}
+		return nil, fmt.Errorf("不支持的编码 %s", label)
+	}
+	var root node
+	if err := d.Decode(&root); err != nil {
+		return nil, errors.New("无法解析 Soundpad 列表")
+	}
+
+	var entries []soundListEntry
+	var walk func(n node)
+	walk = func(n node) {
+		if strings.EqualFold(n.XMLName.Local, "Sound") {
+			var e soundListEntry
+			var artist string
+			for _, attr := range n.Attrs {
+				switch strings.ToLower(attr.Name.Local) {
+				case "url", "path", "file":
+					e.Path = attr.Value
+				case "title":
+					e.Name = attr.Value
+				case "artist":
+					artist = attr.Value
+				case "hotkey", "shortcut":
+					e.Hotkey = attr.Value
+				}
+			}
+			if e.Name != "" && artist != "" {
+				e.Name = artist + " - " + e.Name
+			}
+			entries = append(entries, e)
+		}
+		for _, child := range n.Nodes {
+			walk(child)
+		}
+	}
+	walk(root)
+	return entries, nil
+}
+
+// parseCSVSoundList reads rows of name, path and hotkey. A header row naming
+// the columns (in English or Chinese) may put them in any order. Files saved
+// by Excel in the local code page are converted from GBK.
+func parseCSVSoundList(data []byte) ([]soundListEntry, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		if converted, err := simplifiedchinese.GB18030.NewDecoder().Bytes(data); err == nil {
+			data = converted
+		}
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, errors.New("无法解析 CSV")
+	}
+
+	name, path, hotkey := 0, 1, 2
+	if len(rows) > 0 {
+		header := map[string]int{}
+		for i, col := range rows[0] {
+			switch strings.ToLower(strings.TrimSpace(col)) {
+			case "name", "title", "名称":
+				header["name"] = i
+			case "path", "file", "url", "路径", "文件":
+				header["path"] = i
+			case "hotkey", "shortcut", "热键", "快捷键":
+				header["hotkey"] = i
+			}
+		}
+		if i, ok := header["path"]; ok {
+			path = i
+			name, hotkey = -1, -1
+			if i, ok := header["name"]; ok {
+				name = i
+			}
+			if i, ok := header["hotkey"]; ok {
+				hotkey = i
+			}
+			rows = rows[1:]
+		}
+	}
+
+	field := func(row []string, i int) string {
+		if i < 0 || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+	var entries []soundListEntry
+	for _, row := range rows {
+		e := soundListEntry{Name: field(row, name), Path: field(row, path), Hotkey: field(row, hotkey)}
+		if e == (soundListEntry{}) {
+			continue // Blank line
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// parseJSONSoundList reads an array of {"name", "path", "hotkey"} objects,
+// or an object holding one as "items" or "sounds"
+func parseJSONSoundList(data []byte) ([]soundListEntry, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	var entries []soundListEntry
+	if err := json.Unmarshal(data, &entries); err == nil {
+		return entries, nil
+	}
+	var wrapped struct {
+		Items  []soundListEntry `json:"items"`
+		Sounds []soundListEntry `json:"sounds"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return nil, errors.New("无法解析 JSON")
+	}
+	return append(wrapped.Items, wrapped.Sounds...), nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestMapHotkey(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+		ok       bool
+	}{
+		{"Ctrl+Alt+K", "Ctrl+Alt+K", true},
+		{"control-shift-f5", "Ctrl+Shift+F5", true},
+		{"LAlt K", "Alt+K", true},
+		{"^!k", "Ctrl+Alt+K", true},
+		{"Ctrl+-", "Ctrl+-", true},
+		{"Ctrl--", "Ctrl+-", true},
+		{"Ctrl+Minus", "Ctrl+-", true},
+		{"-", "-", true},
+		{"^-", "Ctrl+-", true},
+		{"Ctrl-Page-Up", "", false}, // One key we don't know, not Ctrl+Page+Up
+		{"Ctrl+K+J", "", false},
+		{"Ctrl", "", false},
+		{"Esc", "", false},
+		{"Ctrl+Escape", "", false},
+		{"", "", false},
+	} {
+		got, ok := mapHotkey(tc.in)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("mapHotkey(%q) = %q, %v; want %q, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestSoundListEscapeHotkey(t *testing.T) {
+	a := NewApp()
+	path := writeTone(t, 440, 0.5, 0.1, 44100)
+	entry := a.importSoundListEntry(soundListEntry{Name: "tone", Hotkey: "ESC"}, path)
+	if entry.Status != importWarning || entry.Reason != reasonUnknownHotkey {
+		t.Errorf("entry = %+v, want a %s warning", entry, reasonUnknownHotkey)
+	}
+	if hotkey := a.Config.AudioList[0].Hotkey; hotkey != "" {
+		t.Errorf("hotkey = %q, want it left unbound", hotkey)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// shutdownTimeout is how long shutdown waits for each group of goroutines
+const shutdownTimeout = 2 * time.Second
+
+// lifecycle tracks a group of long-running goroutines. Each one is handed a
+// context that is cancelled by stop, which then waits for them to return.
+type lifecycle struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex // Keeps Go from adding to wg while stop is waiting on it
+	stopped bool
+}
+
+func newLifecycle(parent context.Context) *lifecycle {
+	ctx, cancel := context.WithCancel(parent)
+	return &lifecycle{ctx: ctx, cancel: cancel}
+}
+
+// Go runs fn in a tracked goroutine. fn must return soon after ctx is done.
+// Once the lifecycle has stopped, fn isn't started at all.
+func (l *lifecycle) Go(fn func(ctx context.Context)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopped {
+		return
+	}
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		fn(l.ctx)
+	}()
+}
+
+// stop cancels the goroutines and waits for them, giving up after timeout.
+// It reports whether they all returned.
+func (l *lifecycle) stop(timeout time.Duration) bool {
+	l.mu.Lock()
+	l.stopped = true
+	l.mu.Unlock()
+	l.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		l.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// shutdown is registered as OnShutdown. Input sources go first so nothing
+// starts new work, then the audio devices are released while the engine
+// loop is still there to confirm their commands, then the loops, and the
+// config is written last.
+func (a *App) shutdown(ctx context.Context) {
+	a.shutdownOnce.Do(func() {
+		if !a.tasks.stop(shutdownTimeout) {
+			runtime.LogWarningf(a.ctx, "Hotkey, tray, update or config goroutines did not stop in time")
+		}
+		a.closeAudio()
+		if !a.audioTasks.stop(shutdownTimeout) {
+			runtime.LogWarningf(a.ctx, "Audio goroutines did not stop in time")
+		}
+		// Anything changed since the writer stopped (e.g. the window size on quit)
+		a.flushConfig()
+	})
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	goruntime "runtime"
+	"testing"
+
+	"github.com/gen2brain/malgo"
+)
+
+func TestShutdownStopsGoroutines(t *testing.T) {
+	before := goruntime.NumGoroutine()
+
+	a := NewApp()
+	a.ctx = context.Background()
+	mctx, err := malgo.InitContext([]malgo.Backend{backendNull}, malgo.ContextConfig{}, nil)
+	if err != nil {
+		t.Fatalf("null backend: %v", err)
+	}
+	a.malCtx = mctx
+	device, format, err := a.openPlayback(nil, a.Config.Output, a.mainBus)
+	if err != nil {
+		t.Fatalf("open null device: %v", err)
+	}
+	a.audioMu.Lock()
+	a.mainBus.device, a.mainBus.format = device, format
+	a.audioMu.Unlock()
+
+	// What startup runs, short of the window, tray and hotkey hooks
+	a.tasks = newLifecycle(a.ctx)
+	a.audioTasks = newLifecycle(a.ctx)
+	a.tasks.Go(a.runConfigWriter)
+	a.tasks.Go(a.runFolderWatcher)
+	a.audioTasks.Go(a.runEngine)
+	a.audioTasks.Go(a.runMeters)
+	if goruntime.NumGoroutine() < before+4 {
+		t.Fatalf("%d goroutines running, want at least %d", goruntime.NumGoroutine(), before+4)
+	}
+
+	a.shutdown(a.ctx)
+	if !eventually(func() bool { return goruntime.NumGoroutine() <= before }) {
+		buf := make([]byte, 1<<16)
+		t.Errorf("%d goroutines left after shutdown, had %d before:\n%s",
+			goruntime.NumGoroutine(), before, buf[:goruntime.Stack(buf, true)])
+	}
+
+	// Tasks started after shutdown are not run
+	a.tasks.Go(a.runFolderWatcher)
+	if n := goruntime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines after starting a task on a stopped lifecycle, want %d", n, before)
+	}
+}
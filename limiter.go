@@ -0,0 +1,195 @@
+package main
+
+import (
+	"math"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// BusMeter is the level of one output
+type BusMeter struct {
+	Peak          float64 `json:"peak"`           // dBFS after limiting, highest since the last read
+	RMS           float64 `json:"rms"`            // dBFS since the last read
+	GainReduction float64 `json:"gain_reduction"` // dB the limiter is currently pulling down
+	Clips         uint64  `json:"clips"`          // Frames that would have gone past 0 dBFS without the limiter
+}
+
+// OutputMeters covers both outputs
+type OutputMeters struct {
+	Main BusMeter `json:"main"`
+	Aux  BusMeter `json:"aux"`
+}
+
+const (
+	defaultLimiterCeiling = -1.0 // dBFS
+	minLimiterCeiling     = -12.0
+	limiterLookahead      = 0.005 // Seconds
+	limiterRelease        = 0.08  // Seconds
+	meterFloor            = -96.0 // dBFS reported for silence
+)
+
+// limiter is a look-ahead brickwall limiter. The signal is delayed by the
+// look-ahead so the gain can ramp down before a peak arrives instead of
+// clipping it: the wanted gain is held at its minimum over the window and
+// then box-averaged over the same length, which reaches the minimum exactly
+// when the peak comes out. Everything is preallocated; process runs on the
+// audio thread.
+type limiter struct {
+	ceiling float64 // Linear
+	release float64 // Per-frame smoothing coefficient for the gain recovering
+	env     float64 // Gain being applied
+
+	delay [][2]float64
+	held  []float64 // Held minimum gains for the box average
+	sum   float64
+	pos   int
+	n     int64 // Frames processed
+
+	// Sliding minimum of the wanted gain (monotonic deque in a ring)
+	dqIdx  []int64
+	dqVal  []float64
+	dqHead int
+	dqLen  int
+
+	silent int // Consecutive silent input frames
+}
+
+func newLimiter(rate beep.SampleRate, ceilingDB float64) *limiter {
+	size := max(2, int(limiterLookahead*float64(rate)))
+	l := &limiter{
+		ceiling: math.Pow(10, ceilingDB/20),
+		release: 1 - math.Exp(-1/(limiterRelease*float64(rate))),
+		env:     1,
+		delay:   make([][2]float64, size),
+		held:    make([]float64, size),
+		sum:     float64(size),
+		dqIdx:   make([]int64, size+2),
+		dqVal:   make([]float64, size+2),
+		silent:  size,
+	}
+	for i := range l.held {
+		l.held[i] = 1
+	}
+	return l
+}
+
+// idle reports whether the look-ahead holds nothing but silence
+func (l *limiter) idle() bool {
+	return l.silent >= len(l.delay)
+}
+
+// process limits frames in place and returns how many input frames were over 0 dBFS
+func (l *limiter) process(frames [][2]float64) (overs int) {
+	size := len(l.delay)
+	capacity := len(l.dqVal)
+	for i, x := range frames {
+		p := math.Max(math.Abs(x[0]), math.Abs(x[1]))
+		if p > 1 {
+			overs++
+		}
+		if p == 0 {
+			l.silent++
+		} else {
+			l.silent = 0
+		}
+		g := 1.0
+		if p > l.ceiling {
+			g = l.ceiling / p
+		}
+
+		// Hold the minimum over size+1 frames, so that the box average below
+		// has fully reached it by the time this frame leaves the delay line
+		for l.dqLen > 0 && l.dqVal[(l.dqHead+l.dqLen-1)%capacity] >= g {
+			l.dqLen--
+		}
+		back := (l.dqHead + l.dqLen) % capacity
+		l.dqIdx[back], l.dqVal[back] = l.n, g
+		l.dqLen++
+		if l.dqIdx[l.dqHead] < l.n-int64(size) {
+			l.dqHead = (l.dqHead + 1) % capacity
+			l.dqLen--
+		}
+		held := l.dqVal[l.dqHead]
+
+		l.sum += held - l.held[l.pos]
+		l.held[l.pos] = held
+		target := l.sum / float64(size)
+
+		if target < l.env {
+			l.env = target
+		} else {
+			l.env += (target - l.env) * l.release
+		}
+
+		out := l.delay[l.pos]
+		l.delay[l.pos] = x
+		frames[i] = [2]float64{l.clip(out[0] * l.env), l.clip(out[1] * l.env)}
+
+		l.n++
+		l.pos++
+		if l.pos == size {
+			l.pos = 0
+			// Stop rounding errors in the running sum from accumulating
+			l.sum = 0
+			for _, h := range l.held {
+				l.sum += h
+			}
+		}
+	}
+	return overs
+}
+
+// clip is the last line of defence against rounding in the gain computation
+func (l *limiter) clip(x float64) float64 {
+	return math.Max(-l.ceiling, math.Min(l.ceiling, x))
+}
+
+// gainReduction is the current reduction in dB (0 or positive)
+func (l *limiter) gainReduction() float64 {
+	return -toDB(l.env)
+}
+
+func toDB(x float64) float64 {
+	if x <= 0 {
+		return meterFloor
+	}
+	return math.Max(meterFloor, 20*math.Log10(x))
+}
+
+// meterLevels adds up a bus's output between meter reads
+type meterLevels struct {
+	peak  float64 // Highest sample
+	sumSq float64 // Sum of squared samples
+	count uint64  // Samples
+}
+
+// meter takes the bus's levels since the last call. Only the meter loop calls this.
+func (b *bus) meter() BusMeter {
+	var l meterLevels
+	if b.levelsReady.Load() {
+		l = b.levels
+		b.levelsReady.Store(false)
+	}
+	var rms float64
+	if l.count > 0 {
+		rms = math.Sqrt(l.sumSq / float64(l.count))
+	}
+	return BusMeter{
+		Peak:          toDB(l.peak),
+		RMS:           toDB(rms),
+		GainReduction: math.Float64frombits(b.reduction.Load()),
+		Clips:         b.clips.Load(),
+	}
+}
+
+// GetOutputMeters returns each output's latest levels, gain reduction and clip count
+func (a *App) GetOutputMeters() OutputMeters {
+	status := a.GetPlaybackStatus()
+	return OutputMeters{Main: status.Main, Aux: status.Aux}
+}
+
+// ResetClipCounters zeroes both outputs' clip counters
+func (a *App) ResetClipCounters() {
+	a.mainBus.clips.Store(0)
+	a.auxBus.clips.Store(0)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMeterWhilePlaying(t *testing.T) {
+	a := newHeadlessApp(t)
+	rate := int(a.mainBus.format.sampleRate)
+	path := writeTone(t, 440, 0.5, 1, rate)
+	a.startClip("tone", path, false, nil, transition{mode: transitionCut}, false)
+
+	// Read as the meter loop does, racing the callback, once the clip's
+	// start is out of the way
+	time.Sleep(100 * time.Millisecond)
+	a.mainBus.meter()
+	var loudest, rms float64 = meterFloor, meterFloor
+	for range 40 {
+		m := a.mainBus.meter()
+		if m.Peak > loudest {
+			loudest, rms = m.Peak, m.RMS
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	want := 20 * math.Log10(0.5)
+	if math.Abs(loudest-want) > 0.5 {
+		t.Errorf("peak = %.2f dBFS, want %.2f", loudest, want)
+	}
+	// A sine's RMS is 3 dB under its peak
+	if math.Abs(rms-(want-3)) > 0.5 {
+		t.Errorf("RMS = %.2f dBFS, want %.2f", rms, want-3)
+	}
+}
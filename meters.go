@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// VoiceProgress is one clip playing on one output
+type VoiceProgress struct {
+	ID       string  `json:"id"`
+	Bus      string  `json:"bus"`      // "main" or "aux"
+	Position float64 `json:"position"` // Seconds
+	Duration float64 `json:"duration"` // Seconds
+	Paused   bool    `json:"paused"`
+}
+
+// PlaybackStatus is pushed to the frontend as "playback-status"
+type PlaybackStatus struct {
+	Playing []VoiceProgress `json:"playing"`
+	Main    BusMeter        `json:"main"`
+	Aux     BusMeter        `json:"aux"`
+}
+
+// meterInterval is how often "playback-status" is emitted while anything is audible
+const meterInterval = 50 * time.Millisecond
+
+// runMeters samples the buses and pushes their state to the frontend. It
+// keeps quiet while everything is idle, after one final idle update.
+// The engine loop wakes it early when a clip stops.
+func (a *App) runMeters(ctx context.Context) {
+	ticker := time.NewTicker(meterInterval)
+	defer ticker.Stop()
+
+	wasActive := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-a.meterWake:
+		}
+
+		status := a.samplePlayback()
+		a.playback.Store(status)
+
+		active := len(status.Playing) > 0 || status.Main.Peak > meterFloor || status.Aux.Peak > meterFloor
+		if active || wasActive {
+			runtime.EventsEmit(a.ctx, "playback-status", status)
+		}
+		wasActive = active
+	}
+}
+
+// samplePlayback reads the voices and meters of both buses
+func (a *App) samplePlayback() *PlaybackStatus {
+	status := &PlaybackStatus{
+		Playing: []VoiceProgress{},
+		Main:    a.mainBus.meter(),
+		Aux:     a.auxBus.meter(),
+	}
+	for _, b := range []struct {
+		name string
+		bus  *bus
+	}{{"main", a.mainBus}, {"aux", a.auxBus}} {
+		if v := b.bus.playing.Load(); v != nil {
+			status.Playing = append(status.Playing, VoiceProgress{
+				ID:       v.id,
+				Bus:      b.name,
+				Position: v.position(),
+				Duration: v.duration,
+				Paused:   v.source != nil && v.source.paused.Load(),
+			})
+		}
+	}
+	return status
+}
+
+// GetPlaybackStatus returns the latest state pushed by the meter loop
+func (a *App) GetPlaybackStatus() PlaybackStatus {
+	if status := a.playback.Load(); status != nil {
+		return *status
+	}
+	return PlaybackStatus{Playing: []VoiceProgress{}}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/gen2brain/malgo"
+	"github.com/gopxl/beep/v2"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// OutputSettings are the advanced playback device parameters.
+// Zero values mean "let the device/backend decide".
+type OutputSettings struct {
+	SampleRate      uint32  `json:"sample_rate"`      // Hz, 0 = device native rate
+	Channels        uint32  `json:"channels"`         // 0 = device native channel count
+	PeriodSize      uint32  `json:"period_size"`      // Frames per callback, 0 = backend default
+	Periods         uint32  `json:"periods"`          // Number of periods in the device buffer, 0 = backend default
+	ResampleQuality int     `json:"resample_quality"` // beep.Resample quality (1-64)
+	Exclusive       bool    `json:"exclusive"`        // WASAPI exclusive mode
+	LimiterCeiling  float64 `json:"limiter_ceiling"`  // dBFS the output limiter holds peaks under
+}
+
+// OutputStatus reports what a playback device actually negotiated
+type OutputStatus struct {
+	Active     bool   `json:"active"`
+	Device     string `json:"device"` // Friendly name, empty for the system default
+	SampleRate uint32 `json:"sample_rate"`
+	Channels   uint32 `json:"channels"`
+	Format     string `json:"format"`
+	PeriodSize uint32 `json:"period_size"` // Frames per callback as last observed, 0 before the first callback
+	Exclusive  bool   `json:"exclusive"`
+	Dropped    uint64 `json:"dropped"` // Commands lost because the audio thread fell behind
+}
+
+// AudioStatus is the negotiated state of the whole output engine
+type AudioStatus struct {
+	Backend string       `json:"backend"`
+	Main    OutputStatus `json:"main"`
+	Aux     OutputStatus `json:"aux"`
+}
+
+const defaultResampleQuality = 4
+
+// outputFormat is what a running playback device negotiated
+type outputFormat struct {
+	name       string
+	sampleRate beep.SampleRate
+	channels   int
+	format     malgo.FormatType
+	exclusive  bool
+}
+
+// normalize clamps settings into ranges malgo and beep accept
+func (s OutputSettings) normalize() OutputSettings {
+	if s.ResampleQuality < 1 || s.ResampleQuality > 64 {
+		s.ResampleQuality = defaultResampleQuality
+	}
+	if s.SampleRate != 0 && (s.SampleRate < 8000 || s.SampleRate > 384000) {
+		s.SampleRate = 0
+	}
+	if s.Channels > 8 {
+		s.Channels = 0
+	}
+	if s.LimiterCeiling > 0 || s.LimiterCeiling < minLimiterCeiling {
+		s.LimiterCeiling = defaultLimiterCeiling
+	}
+	return s
+}
+
+// openPlayback initialises and starts a playback device with the given output
+// settings. A nil info opens the system default device.
+func (a *App) openPlayback(info *malgo.DeviceInfo, settings OutputSettings, b *bus) (*malgo.Device, outputFormat, error) {
+	deviceConfig := malgo.DefaultDeviceConfig(malgo.Playback)
+	deviceConfig.Playback.Format = malgo.FormatF32
+	deviceConfig.Playback.Channels = settings.Channels
+	deviceConfig.SampleRate = settings.SampleRate
+	deviceConfig.PeriodSizeInFrames = settings.PeriodSize
+	deviceConfig.Periods = settings.Periods
+	deviceConfig.Alsa.NoMMap = 1
+	if info != nil {
+		deviceConfig.Playback.DeviceID = info.ID.Pointer()
+	}
+	if settings.Exclusive {
+		deviceConfig.Playback.ShareMode = malgo.Exclusive
+	}
+
+	// Set once the device is initialised and before it is started,
+	// so the audio thread always sees the negotiated value.
+	var channels int
+	callbacks := malgo.DeviceCallbacks{
+		Data: func(pOutput, pInput []byte, framecount uint32) {
+			b.onSamples(pOutput, framecount, channels)
+		},
+	}
+
+	device, err := malgo.InitDevice(a.malCtx.Context, deviceConfig, callbacks)
+	if err != nil && settings.Exclusive {
+		runtime.LogErrorf(a.ctx, "Exclusive mode unavailable (%v), falling back to shared", err)
+		deviceConfig.Playback.ShareMode = malgo.Shared
+		device, err = malgo.InitDevice(a.malCtx.Context, deviceConfig, callbacks)
+	}
+	if err != nil {
+		return nil, outputFormat{}, err
+	}
+
+	format := outputFormat{
+		sampleRate: beep.SampleRate(device.SampleRate()),
+		channels:   int(device.PlaybackChannels()),
+		format:     device.PlaybackFormat(),
+		exclusive:  deviceConfig.Playback.ShareMode == malgo.Exclusive,
+	}
+	if info != nil {
+		format.name = info.Name()
+	}
+	channels = format.channels
+	b.limiter.Store(newLimiter(format.sampleRate, settings.LimiterCeiling))
+
+	if err := device.Start(); err != nil {
+		device.Uninit()
+		return nil, outputFormat{}, err
+	}
+	return device, format, nil
+}
+
+// status reports the bus's negotiated format for the frontend.
+// Callers must hold App.audioMu.
+func (b *bus) status() OutputStatus {
+	if b.device == nil {
+		return OutputStatus{}
+	}
+	f := b.format
+	return OutputStatus{
+		Active:     true,
+		Device:     f.name,
+		SampleRate: uint32(f.sampleRate),
+		Channels:   uint32(f.channels),
+		Format:     formatNames[f.format],
+		PeriodSize: b.period.Load(),
+		Exclusive:  f.exclusive,
+		Dropped:    b.dropped.Load(),
+	}
+}
+
+// resampleTo converts s to the device rate, skipping the work when they already match
+func resampleTo(quality int, from, to beep.SampleRate, s beep.Streamer) beep.Streamer {
+	if to == 0 || from == to {
+		return s
+	}
+	return beep.Resample(quality, from, to, s)
+}
+
+// putSample writes one F32 little-endian sample
+func putSample(p []byte, x float64) {
+	binary.LittleEndian.PutUint32(p, math.Float32bits(float32(x)))
+}
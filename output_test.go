@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gen2brain/malgo"
+)
+
+// newHeadlessApp returns an App whose buses play on the null backend, with
+// the engine loop running, so the audio path can be checked without a sound
+// card
+func newHeadlessApp(t *testing.T) *App {
+	t.Helper()
+	a := NewApp()
+	a.ctx = context.Background()
+
+	mctx, err := malgo.InitContext([]malgo.Backend{backendNull}, malgo.ContextConfig{}, nil)
+	if err != nil {
+		t.Fatalf("null backend: %v", err)
+	}
+	a.malCtx = mctx
+	a.backend = backendNull
+
+	for _, b := range []*bus{a.mainBus, a.auxBus} {
+		device, format, err := a.openPlayback(nil, a.Config.Output, b)
+		if err != nil {
+			t.Fatalf("open null device: %v", err)
+		}
+		a.audioMu.Lock()
+		b.device, b.format = device, format
+		a.audioMu.Unlock()
+	}
+
+	a.audioTasks = newLifecycle(context.Background())
+	a.audioTasks.Go(a.runEngine)
+	t.Cleanup(func() {
+		a.closeAudio()
+		a.audioTasks.stop(time.Second)
+	})
+	return a
+}
+
+// writeTone saves a stereo sine as a WAV in a temporary folder
+func writeTone(t *testing.T, freq, amp, seconds float64, rate int) string {
+	t.Helper()
+	n := int(seconds * float64(rate))
+	samples := make([]float32, 0, n*2)
+	for i := range n {
+		s := float32(amp * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+		samples = append(samples, s, s)
+	}
+	path := filepath.Join(t.TempDir(), "tone.wav")
+	if err := writeClip(path, samples, rate); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// waitIdle waits for the clip to finish playing on every bus
+func waitIdle(t *testing.T, a *App, timeout time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for a.playingID() != "" {
+		if time.Now().After(deadline) {
+			t.Fatalf("%q still playing after %v", a.playingID(), timeout)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// captureLevels drains r and returns the peak and the number of frames
+// louder than -60 dBFS
+func captureLevels(r *ringBuffer) (peak float64, loud int) {
+	buf := make([]float32, r.buffered())
+	n := r.read(buf)
+	for i := 0; i+1 < n; i += 2 {
+		s := math.Max(math.Abs(float64(buf[i])), math.Abs(float64(buf[i+1])))
+		peak = math.Max(peak, s)
+		if s > 0.001 {
+			loud++
+		}
+	}
+	return peak, loud
+}
+
+func TestPlaybackHeadless(t *testing.T) {
+	a := newHeadlessApp(t)
+	rate := int(a.mainBus.format.sampleRate)
+	const amp, seconds = 0.5, 0.25
+	path := writeTone(t, 440, amp, seconds, rate)
+
+	for _, stream := range []bool{false, true} {
+		sink := a.mainBus.captureOutput(rate * 2)
+		a.startClip("tone", path, stream, nil, transition{mode: transitionCut}, false)
+		if got := a.playingID(); got != "tone" {
+			t.Fatalf("stream=%v: playingID = %q after startClip", stream, got)
+		}
+		waitIdle(t, a, 2*time.Second)
+		time.Sleep(50 * time.Millisecond) // Let the limiter's look-ahead drain
+		a.mainBus.captureOutput(0)
+
+		peak, loud := captureLevels(sink)
+		if math.Abs(peak-amp) > 0.02 {
+			t.Errorf("stream=%v: peak = %.3f, want %.3f", stream, peak, amp)
+		}
+		wantLoud := int(seconds * float64(rate))
+		if loud < wantLoud*95/100 || loud > wantLoud*105/100 {
+			t.Errorf("stream=%v: %d audible frames, want about %d", stream, loud, wantLoud)
+		}
+	}
+}
+
+func TestStopSilencesOutput(t *testing.T) {
+	a := newHeadlessApp(t)
+	rate := int(a.mainBus.format.sampleRate)
+	path := writeTone(t, 440, 0.5, 2, rate)
+
+	a.startClip("tone", path, false, nil, transition{mode: transitionCut}, false)
+	time.Sleep(100 * time.Millisecond)
+	a.stopAudioNow()
+	if got := a.playingID(); got != "" {
+		t.Fatalf("playingID = %q after stopAudioNow", got)
+	}
+
+	time.Sleep(50 * time.Millisecond) // Past the limiter's look-ahead
+	sink := a.mainBus.captureOutput(rate)
+	time.Sleep(100 * time.Millisecond)
+	a.mainBus.captureOutput(0)
+	if sink.buffered() == 0 {
+		t.Fatal("nothing captured")
+	}
+	if peak, _ := captureLevels(sink); peak != 0 {
+		t.Errorf("peak = %v after stop, want silence", peak)
+	}
+}
+
+func TestPreviewLeavesAuxPlaying(t *testing.T) {
+	a := newHeadlessApp(t)
+	rate := int(a.mainBus.format.sampleRate)
+	path := writeTone(t, 440, 0.5, 2, rate)
+	a.Config.AudioList = append(a.Config.AudioList, &AudioItem{ID: "preview", Path: path})
+
+	a.startClip("tone", path, false, nil, transition{mode: transitionCut}, true)
+	a.PreviewAudioEffects("preview", nil)
+	if got := a.mainBus.playingID(); got != "preview" {
+		t.Errorf("main bus playing %q, want the preview", got)
+	}
+	if got := a.auxBus.playingID(); got != "tone" {
+		t.Errorf("aux bus playing %q, want the clip from before the preview", got)
+	}
+}
+
+func TestSpeedScalesProgress(t *testing.T) {
+	a := newHeadlessApp(t)
+	clipRate := 44100
+	path := writeTone(t, 440, 0.5, 4, clipRate)
+	chain := []EffectConfig{{Type: "speed", Params: map[string]float64{"rate": 2}}}
+
+	a.startClip("tone", path, false, chain, transition{mode: transitionCut}, false)
+	v := a.mainBus.playing.Load()
+	if v == nil {
+		t.Fatal("not playing")
+	}
+	if math.Abs(v.duration-2) > 0.01 {
+		t.Errorf("duration = %.3fs, want 2s for 4s at double speed", v.duration)
+	}
+
+	// Seeking to 1s as played lands 2s into the clip
+	a.SeekAudio("tone", 1)
+	if frame := v.source.frames.Load(); frame < int64(2*clipRate) || frame > int64(2.2*float64(clipRate)) {
+		t.Errorf("at clip frame %d after seeking to 1s, want about %d", frame, 2*clipRate)
+	}
+	if pos := v.position(); pos < 1 || pos > 1.1 {
+		t.Errorf("position = %.3fs after seeking to 1s", pos)
+	}
+}
@@ -0,0 +1,483 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// A soundboard pack (.dtpack) is a zip holding manifest.json and the clips
+// under audio/. Item paths in the manifest are entry names inside the zip.
+const (
+	packExt          = ".dtpack"
+	packManifestName = "manifest.json"
+	packVersion      = 1
+	maxPackFileBytes = 256 * 1024 * 1024 // Largest clip unpacked; the import settings decide what is kept
+)
+
+// PackManifest describes a soundboard pack. Device settings stay behind;
+// they belong to the machine, not the soundboard.
+type PackManifest struct {
+	Version   int              `json:"version"`
+	Items     []*AudioItem     `json:"items"`
+	Groups    []*ClipGroup     `json:"groups"`
+	Presets   []*EffectPreset  `json:"presets"`
+	Playback  PlaybackSettings `json:"playback"`
+	Transport TransportHotkeys `json:"transport"`
+}
+
+// PackImportOptions control how a pack joins the current soundboard
+type PackImportOptions struct {
+	Replace bool   `json:"replace"` // Replace the clips, groups, presets and playback settings instead of adding to them
+	Hotkeys string `json:"hotkeys"` // A hotkey already in use: "skip" leaves the imported one unbound, "rebind" moves it over
+}
+
+const (
+	packHotkeysSkip   = "skip"
+	packHotkeysRebind = "rebind"
+)
+
+// ExportPack saves the soundboard with its audio files as a .dtpack.
+// Returns "" if cancelled, "OK", or the clips that had to be left out.
+func (a *App) ExportPack() string {
+	dest, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Soundboard",
+		DefaultFilename: "soundboard" + packExt,
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Soundboard Pack", Pattern: "*" + packExt},
+		},
+	})
+	if err != nil || dest == "" {
+		return ""
+	}
+	if !strings.EqualFold(filepath.Ext(dest), packExt) {
+		dest += packExt
+	}
+
+	skipped, err := a.exportPack(dest)
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to export %s: %v", dest, err)
+		return "Error: " + err.Error()
+	}
+	if len(skipped) > 0 {
+		return strings.Join(skipped, "\n")
+	}
+	return "OK"
+}
+
+// exportPack writes the pack to dest, returning the clips whose files couldn't be read
+func (a *App) exportPack(dest string) ([]string, error) {
+	a.mu.Lock()
+	manifest := PackManifest{
+		Version:   packVersion,
+		Playback:  a.Config.Playback,
+		Transport: a.Config.Transport,
+	}
+	for _, item := range a.Config.AudioList {
+		copied := *item
+		manifest.Items = append(manifest.Items, &copied)
+	}
+	for _, g := range a.Config.Groups {
+		copied := *g
+		copied.Items = append([]GroupItem(nil), g.Items...)
+		copied.Position, copied.Last = 0, ""
+		manifest.Groups = append(manifest.Groups, &copied)
+	}
+	for _, p := range a.Config.Presets {
+		copied := *p
+		manifest.Presets = append(manifest.Presets, &copied)
+	}
+	a.mu.Unlock()
+
+	// Write next to dest and rename, so a failed export leaves no half-written pack
+	f, err := os.CreateTemp(filepath.Dir(dest), filepath.Base(dest)+".tmp*")
+	if err != nil {
+		return nil, err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp) // Fails harmlessly once renamed
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	entries := make(map[string]string) // Clip file on disk -> entry name
+	var skipped []string
+	items := manifest.Items[:0]
+	for _, item := range manifest.Items {
+		entry, ok := entries[item.Path]
+		if !ok {
+			in, err := os.Open(item.Path)
+			if err != nil {
+				skipped = append(skipped, fmt.Sprintf("Error (%s): %s", item.Name, err.Error()))
+				continue
+			}
+			entry = fmt.Sprintf("audio/%d_%s", len(entries)+1, filepath.Base(item.Path))
+			err = addPackFile(zw, entry, in)
+			in.Close()
+			if err != nil {
+				return nil, err
+			}
+			entries[item.Path] = entry
+		}
+		item.Path = entry
+		items = append(items, item)
+	}
+	manifest.Items = items
+
+	w, err := zw.Create(packManifestName)
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	if err := f.Sync(); err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return skipped, os.Rename(tmp, dest)
+}
+
+// addPackFile copies an open file into the zip
+func addPackFile(zw *zip.Writer, entry string, in io.Reader) error {
+	w, err := zw.Create(entry)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, in)
+	return err
+}
+
+// ImportPack asks for a .dtpack and adds its clips to the library.
+// Returns the import report, or nil if cancelled.
+func (a *App) ImportPack(options PackImportOptions) *ImportReport {
+	src, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Soundboard",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Soundboard Pack", Pattern: "*" + packExt},
+		},
+	})
+	if err != nil || src == "" {
+		return nil
+	}
+	report := a.importPack(src, options)
+	if report.Error == "" {
+		runtime.EventsEmit(a.ctx, "audios-changed")
+	}
+	return &report
+}
+
+// packClip is one extracted, validated file from a pack
+type packClip struct {
+	path      string
+	info      AudioInfo
+	stream    bool  // Over the import limits, imported anyway
+	overLimit error // Which limit, if streamed
+	err       error
+}
+
+func (a *App) importPack(src string, options PackImportOptions) ImportReport {
+	report := ImportReport{Source: filepath.Base(src), Entries: []ImportEntry{}}
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		report.Error = "无法打开音效包"
+		return report
+	}
+	defer zr.Close()
+
+	manifest, err := readPackManifest(&zr.Reader)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	a.mu.Lock()
+	limits := a.Config.Import
+	a.mu.Unlock()
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	// Unpack into a folder of its own in the library
+	name := strings.TrimSuffix(filepath.Base(src), filepath.Ext(src))
+	dir := filepath.Join(a.getLibraryDir(), name+"_"+time.Now().Format("20060102_150405"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	clips := make(map[string]*packClip) // Entry name -> extracted file
+	used := make(map[string]bool)       // File names taken in dir
+	for _, item := range manifest.Items {
+		clip, ok := clips[item.Path]
+		if !ok {
+			clip = &packClip{}
+			if f := files[item.Path]; f == nil {
+				clip.err = errors.New("音效包中缺少文件")
+			} else if clip.path, clip.err = extractPackFile(f, dir, used); clip.err == nil {
+				if clip.info, clip.err = probeAudioFile(clip.path); clip.err == nil {
+					if clip.err = limits.check(clip.info); clip.err != nil && limits.AllowOverLimit {
+						clip.stream, clip.overLimit, clip.err = true, clip.err, nil
+					}
+				}
+				if clip.err != nil {
+					os.Remove(clip.path)
+				}
+			}
+			clips[item.Path] = clip
+		}
+		if clip.err != nil {
+			report.add(ImportEntry{Name: item.Name, Path: item.Path, Status: importFailed, Reason: importReason(clip.err), Message: clip.err.Error()})
+		}
+	}
+	if report.Failed == len(manifest.Items) {
+		os.Remove(dir) // Only if nothing was left in it
+		report.Error = "音效包中没有可导入的音频"
+		return report
+	}
+
+	a.mu.Lock()
+	a.mergePack(manifest, clips, options, &report)
+	a.saveConfig()
+	a.mu.Unlock()
+	return report
+}
+
+// mergePack adds (or swaps in) a pack's contents, giving everything new IDs,
+// and reports each clip added and each hotkey left unbound. Caller holds a.mu.
+func (a *App) mergePack(manifest *PackManifest, clips map[string]*packClip, options PackImportOptions, report *ImportReport) {
+	if options.Replace {
+		a.Config.AudioList = []*AudioItem{}
+		a.Config.Groups = nil
+		if len(manifest.Presets) > 0 {
+			a.Config.Presets = nil
+		}
+		a.Config.Playback = manifest.Playback
+		a.Config.Playback.Mode, a.Config.Playback.Crossfade = normalizeTransition(a.Config.Playback.Mode, a.Config.Playback.Crossfade, transitionCut)
+		a.Config.Playback.FadeOut = max(0, min(a.Config.Playback.FadeOut, maxFadeOut))
+		// The keys are bound below, along with everything else's
+		a.Config.Transport = TransportHotkeys{SeekStep: manifest.Transport.SeekStep}
+		if a.Config.Transport.SeekStep <= 0 {
+			a.Config.Transport.SeekStep = defaultSeekStep
+		}
+	}
+
+	// claim binds a hotkey unless it is taken, returning a warning if it isn't bound
+	owners := a.hotkeyOwners()
+	claim := func(hotkey *string) string {
+		key := strings.ToLower(*hotkey)
+		if key == "" {
+			return ""
+		}
+		if owner, ok := owners[key]; ok {
+			if options.Hotkeys != packHotkeysRebind {
+				taken := fmt.Sprintf("热键 %s 已被占用，未绑定", *hotkey)
+				*hotkey = ""
+				return taken
+			}
+			*owner = ""
+		}
+		owners[key] = hotkey
+		return ""
+	}
+
+	// Transport keys, when replacing
+	if options.Replace {
+		t, from := &a.Config.Transport, manifest.Transport
+		for _, k := range []struct {
+			name     string
+			dst, src *string
+		}{
+			{"暂停/继续", &t.PauseResume, &from.PauseResume},
+			{"后退", &t.SeekBack, &from.SeekBack},
+			{"前进", &t.SeekForward, &from.SeekForward},
+			{"全部停止", &t.StopAll, &from.StopAll},
+			{"紧急停止", &t.Panic, &from.Panic},
+		} {
+			*k.dst = *k.src
+			if taken := claim(k.dst); taken != "" {
+				report.note(k.name, reasonHotkeyTaken, taken)
+			}
+		}
+	}
+
+	// Clips
+	ids := make(map[string]string) // Pack ID -> new ID
+	for _, it := range manifest.Items {
+		clip := clips[it.Path]
+		if clip == nil || clip.err != nil {
+			continue
+		}
+		item := a.newAudioItem(clip.path, clip.info)
+		item.Stream = clip.stream
+		item.Name = it.Name
+		item.Category = it.Category
+		item.Hotkey = it.Hotkey
+		item.Effects = it.Effects
+		item.Mode, item.Crossfade = normalizeTransition(it.Mode, it.Crossfade, "")
+		a.Config.AudioList = append(a.Config.AudioList, item)
+		ids[it.ID] = item.ID
+
+		info := clip.info
+		entry := ImportEntry{Name: item.Name, Path: clip.path, Status: importAdded, ID: item.ID, Info: &info}
+		if clip.stream {
+			entry.warn(reasonStreamed, clip.overLimit.Error()+"，将从磁盘播放")
+		}
+		if taken := claim(&item.Hotkey); taken != "" {
+			entry.warn(reasonHotkeyTaken, taken)
+		}
+		report.add(entry)
+	}
+
+	// Groups, keeping only clips that made it
+	now := time.Now().UnixNano()
+	for i, g := range manifest.Groups {
+		group := *g
+		group.ID = fmt.Sprintf("g%d_%d", now, i)
+		group.Items = nil
+		group.Position, group.Last = 0, ""
+		for _, it := range g.Items {
+			if id, ok := ids[it.ID]; ok {
+				group.Items = append(group.Items, GroupItem{ID: id, Weight: it.Weight})
+			}
+		}
+		if len(group.Items) == 0 {
+			continue
+		}
+		if taken := claim(&group.Hotkey); taken != "" {
+			report.note(group.Name, reasonHotkeyTaken, taken)
+		}
+		a.Config.Groups = append(a.Config.Groups, &group)
+	}
+
+	// Effect presets: new ones are added, ones with a known ID are left as they are
+	for _, p := range manifest.Presets {
+		if a.findPreset(p.ID) != nil {
+			continue
+		}
+		preset := *p
+		if taken := claim(&preset.Hotkey); taken != "" {
+			report.note(preset.Name, reasonHotkeyTaken, taken)
+		}
+		a.Config.Presets = append(a.Config.Presets, &preset)
+	}
+	if a.findPreset(a.Config.Voice.Preset) == nil {
+		a.Config.Voice.Preset = ""
+	}
+	if a.findPreset(a.Config.Voice.ClipPreset) == nil {
+		a.Config.Voice.ClipPreset = ""
+	}
+}
+
+// findPreset returns the effect preset with an ID, or nil. Caller holds a.mu.
+func (a *App) findPreset(id string) *EffectPreset {
+	for _, p := range a.Config.Presets {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// hotkeyOwners maps every bound hotkey (lower-cased) to the setting holding
+// it, so a conflict can be resolved by clearing the old one. Caller holds a.mu.
+func (a *App) hotkeyOwners() map[string]*string {
+	owners := make(map[string]*string)
+	add := func(hotkey *string) {
+		if *hotkey != "" {
+			owners[strings.ToLower(*hotkey)] = hotkey
+		}
+	}
+	for _, item := range a.Config.AudioList {
+		add(&item.Hotkey)
+	}
+	for _, g := range a.Config.Groups {
+		add(&g.Hotkey)
+	}
+	for _, p := range a.Config.Presets {
+		add(&p.Hotkey)
+	}
+	t := &a.Config.Transport
+	for _, k := range []*string{&t.PauseResume, &t.SeekBack, &t.SeekForward, &t.StopAll, &t.Panic} {
+		add(k)
+	}
+	add(&a.Config.Replay.Hotkey)
+	return owners
+}
+
+func readPackManifest(zr *zip.Reader) (*PackManifest, error) {
+	f, err := zr.Open(packManifestName)
+	if err != nil {
+		return nil, errors.New("不是有效的音效包")
+	}
+	defer f.Close()
+
+	var manifest PackManifest
+	if err := json.NewDecoder(f).Decode(&manifest); err != nil {
+		return nil, errors.New("音效包清单损坏")
+	}
+	if manifest.Version > packVersion {
+		return nil, errors.New("音效包来自更新的版本，请先升级")
+	}
+	return &manifest, nil
+}
+
+// extractPackFile unpacks one clip into dir under its own (de-duplicated)
+// name. Only the base name of the entry is used, so a crafted pack can't
+// write outside dir, and nothing bigger than maxPackFileBytes is written.
+func extractPackFile(f *zip.File, dir string, used map[string]bool) (string, error) {
+	name := path.Base(strings.ReplaceAll(f.Name, "\\", "/"))
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext != ".mp3" && ext != ".wav" {
+		return "", errors.New("不支持的文件类型")
+	}
+	if f.UncompressedSize64 > maxPackFileBytes {
+		return "", errors.New("文件过大")
+	}
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	for i := 2; used[strings.ToLower(name)]; i++ {
+		name = fmt.Sprintf("%s_%d%s", stem, i, filepath.Ext(name))
+	}
+	used[strings.ToLower(name)] = true
+
+	in, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	dest := filepath.Join(dir, name)
+	out, err := os.Create(dest)
+	if err != nil {
+		return "", err
+	}
+	n, err := io.Copy(out, io.LimitReader(in, maxPackFileBytes+1))
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && n > maxPackFileBytes {
+		err = errors.New("文件过大")
+	}
+	if err != nil {
+		os.Remove(dest)
+		return "", err
+	}
+	return dest, nil
+}
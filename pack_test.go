@@ -0,0 +1,171 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergePackReplace(t *testing.T) {
+	a := NewApp()
+	a.Config.Replay.Hotkey = "F5"
+	a.Config.Transport.Panic = "F9"
+
+	manifest := &PackManifest{
+		Items: []*AudioItem{
+			{ID: "1", Name: "boom", Path: "audio/1_boom.wav", Category: "sfx/impacts", Hotkey: "F6"},
+		},
+		Transport: TransportHotkeys{PauseResume: "F5", SeekBack: "F6", StopAll: "F7", SeekStep: 2},
+	}
+	clips := map[string]*packClip{"audio/1_boom.wav": {path: "/library/pack/1_boom.wav"}}
+	var report ImportReport
+	a.mergePack(manifest, clips, PackImportOptions{Replace: true, Hotkeys: packHotkeysSkip}, &report)
+
+	if len(a.Config.AudioList) != 1 {
+		t.Fatalf("%d clips after replacing, want 1", len(a.Config.AudioList))
+	}
+	item := a.Config.AudioList[0]
+	if item.Category != "sfx/impacts" {
+		t.Errorf("category = %q, want it from the pack", item.Category)
+	}
+
+	// The replay key stays, so the pack's pause key can't have it; the
+	// transport keys go first, so the clip loses F6 to seek back
+	want := TransportHotkeys{SeekBack: "F6", StopAll: "F7", SeekStep: 2}
+	if a.Config.Transport != want {
+		t.Errorf("transport = %+v, want %+v", a.Config.Transport, want)
+	}
+	if item.Hotkey != "" {
+		t.Errorf("clip hotkey = %q, want it left unbound", item.Hotkey)
+	}
+	taken := 0
+	for _, e := range report.Entries {
+		if e.Reason == reasonHotkeyTaken {
+			taken++
+		}
+	}
+	if report.Added != 1 || taken != 2 {
+		t.Errorf("report: %d added, %d hotkeys taken; want 1 and 2: %+v", report.Added, taken, report.Entries)
+	}
+}
+
+func TestPackRoundTrip(t *testing.T) {
+	base := t.TempDir()
+	src := filepath.Join(base, "clips")
+	writeClipAt(t, filepath.Join(src, "boom.wav"))
+	writeClipAt(t, filepath.Join(src, "sub", "boom.wav")) // Same name, another file
+
+	a := NewApp()
+	a.Config.AudioList = []*AudioItem{
+		{ID: "1", Name: "boom", Path: filepath.Join(src, "boom.wav"), Category: "sfx", Hotkey: "F6"},
+		{ID: "2", Name: "boom again", Path: filepath.Join(src, "boom.wav")}, // Shares the file
+		{ID: "3", Name: "other boom", Path: filepath.Join(src, "sub", "boom.wav")},
+		{ID: "4", Name: "gone", Path: filepath.Join(src, "gone.wav")},
+	}
+	a.Config.Groups = []*ClipGroup{
+		{ID: "g", Name: "booms", Mode: groupRandom, Items: []GroupItem{{ID: "3", Weight: 2}, {ID: "4"}, {ID: "1"}}, Position: 1, Last: "1"},
+	}
+	dest := filepath.Join(base, "board"+packExt)
+	skipped, err := a.exportPack(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 1 {
+		t.Errorf("skipped %v, want the missing clip", skipped)
+	}
+
+	withAppDirs(t, appDirs{exe: base, data: filepath.Join(base, "data")})
+	b := NewApp()
+	report := b.importPack(dest, PackImportOptions{Hotkeys: packHotkeysSkip})
+	if report.Error != "" || report.Added != 3 {
+		t.Fatalf("report = %+v, want 3 clips added", report)
+	}
+
+	byName := map[string]*AudioItem{}
+	for _, item := range b.Config.AudioList {
+		byName[item.Name] = item
+	}
+	boom, again, other := byName["boom"], byName["boom again"], byName["other boom"]
+	if boom == nil || again == nil || other == nil || len(byName) != 3 {
+		t.Fatalf("imported %v", b.Config.AudioList)
+	}
+	for _, item := range []*AudioItem{boom, again, other} {
+		if item.ID == "1" || item.ID == "2" || item.ID == "3" {
+			t.Errorf("%s kept the pack's ID %s", item.Name, item.ID)
+		}
+		if _, inside := pathWithin(b.libraryRoot(), item.Path); !inside {
+			t.Errorf("%s unpacked to %s, outside the library", item.Name, item.Path)
+		}
+		if _, err := os.Stat(item.Path); err != nil {
+			t.Errorf("%s: %v", item.Name, err)
+		}
+	}
+	if boom.Path != again.Path {
+		t.Errorf("clips sharing a file unpacked to %s and %s", boom.Path, again.Path)
+	}
+	if boom.Path == other.Path || filepath.Dir(boom.Path) != filepath.Dir(other.Path) {
+		t.Errorf("files with the same name unpacked to %s and %s", boom.Path, other.Path)
+	}
+	if boom.Category != "sfx" || boom.Hotkey != "F6" {
+		t.Errorf("boom came back with category %q and hotkey %q", boom.Category, boom.Hotkey)
+	}
+
+	if len(b.Config.Groups) != 1 {
+		t.Fatalf("%d groups imported, want 1", len(b.Config.Groups))
+	}
+	g := b.Config.Groups[0]
+	want := []GroupItem{{ID: other.ID, Weight: 2}, {ID: boom.ID}}
+	if len(g.Items) != len(want) || g.Items[0] != want[0] || g.Items[1] != want[1] {
+		t.Errorf("group items = %v, want %v", g.Items, want)
+	}
+	if g.ID == "g" || g.Position != 0 || g.Last != "" {
+		t.Errorf("group imported with ID %q, position %d, last %q", g.ID, g.Position, g.Last)
+	}
+}
+
+func TestExtractPackFileStaysInFolder(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	names := []string{"../../evil.wav", "..\\..\\evil.wav", "/tmp/evil.wav", "C:\\Windows\\evil.wav", "audio/../../evil.wav"}
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("RIFF"))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := t.TempDir()
+	dir := filepath.Join(base, "library", "pack")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	used := map[string]bool{}
+	for _, f := range zr.File {
+		path, err := extractPackFile(f, dir, used)
+		if err != nil {
+			t.Errorf("%s: %v", f.Name, err)
+			continue
+		}
+		if filepath.Dir(path) != dir {
+			t.Errorf("%s extracted to %s, outside %s", f.Name, path, dir)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != len(names) {
+		t.Errorf("%d files in the pack folder, want %d", len(entries), len(names))
+	}
+	for _, escaped := range []string{filepath.Join(base, "evil.wav"), filepath.Join(base, "library", "evil.wav")} {
+		if _, err := os.Stat(escaped); err == nil {
+			t.Errorf("%s written", escaped)
+		}
+	}
+}
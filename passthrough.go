@@ -0,0 +1,282 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gen2brain/malgo"
+	"github.com/gopxl/beep/v2"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// VoiceSettings controls the microphone pass-through to the aux bus (the
+// virtual mic) and which effect presets are active
+type VoiceSettings struct {
+	Enabled    bool   `json:"enabled"`
+	Device     string `json:"device"`      // Capture device ID, "" = default
+	Preset     string `json:"preset"`      // Effect preset ID on the mic, "" = none
+	ClipPreset string `json:"clip_preset"` // Effect preset ID on clips, "" = none
+}
+
+// micMaxLatency bounds how far the pass-through may fall behind the mic
+// when the two devices' clocks drift apart
+const micMaxLatency = 0.06 // Seconds
+
+// micStream plays whatever the capture device has delivered, or silence
+type micStream struct {
+	ring        *ringBuffer
+	buf         []float32
+	maxBuffered int // Samples
+}
+
+func (m *micStream) Stream(samples [][2]float64) (n int, ok bool) {
+	if over := m.ring.buffered() - m.maxBuffered; over > 0 {
+		m.ring.discard(over)
+	}
+
+	want := min(len(samples), len(m.buf)/2)
+	got := m.ring.read(m.buf[:want*2]) / 2
+	for i := 0; i < got; i++ {
+		samples[i] = [2]float64{float64(m.buf[i*2]), float64(m.buf[i*2+1])}
+	}
+	clear(samples[got:])
+	return len(samples), true
+}
+
+func (m *micStream) Err() error {
+	return nil
+}
+
+// micInput is the open pass-through capture device
+type micInput struct {
+	device *malgo.Device
+	stream *micStream
+	rate   beep.SampleRate
+}
+
+func defaultEffectPresets() []*EffectPreset {
+	return []*EffectPreset{
+		{ID: "robot", Name: "机器人", Effects: []EffectConfig{
+			{Type: "robot", Params: map[string]float64{"frequency": 60}},
+		}},
+		{ID: "chipmunk", Name: "花栗鼠", Effects: []EffectConfig{
+			{Type: "pitch", Params: map[string]float64{"semitones": 6}},
+			{Type: "formant", Params: map[string]float64{"shift": 1.2}},
+		}},
+		{ID: "deep", Name: "大叔", Effects: []EffectConfig{
+			{Type: "pitch", Params: map[string]float64{"semitones": -4}},
+			{Type: "eq", Params: map[string]float64{"low": 4}},
+		}},
+		{ID: "hall", Name: "大厅", Effects: []EffectConfig{
+			{Type: "reverb", Params: map[string]float64{"room": 0.85, "mix": 0.35}},
+		}},
+		{ID: "echo", Name: "回声", Effects: []EffectConfig{
+			{Type: "echo"},
+		}},
+		{ID: "radio", Name: "对讲机", Effects: []EffectConfig{
+			{Type: "eq", Params: map[string]float64{"low": -18, "mid": 6, "high": -12}},
+			{Type: "distortion", Params: map[string]float64{"drive": 6, "mix": 0.5}},
+		}},
+	}
+}
+
+// presetEffects returns the effect chain for a preset ID. Caller holds a.mu.
+func (a *App) presetEffects(id string) []EffectConfig {
+	if p := a.findPreset(id); p != nil {
+		return p.Effects
+	}
+	return nil
+}
+
+// restartVoice (re)opens the mic pass-through, following the aux device's rate
+func (a *App) restartVoice() {
+	a.stopVoice()
+
+	a.mu.Lock()
+	settings := a.Config.Voice
+	a.mu.Unlock()
+
+	if !settings.Enabled || a.malCtx == nil {
+		return
+	}
+
+	a.audioMu.Lock()
+	auxOpen, rate := a.auxBus.device != nil, a.auxBus.format.sampleRate
+	a.audioMu.Unlock()
+	if !auxOpen {
+		return // Nowhere to send it
+	}
+
+	var ring *ringBuffer
+	device, _, err := a.openCapture(malgo.Capture, settings.Device, uint32(rate), func(pInput []byte, framecount uint32) {
+		ring.writeF32(pInput, int(framecount))
+	})
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to open pass-through capture device: %v", err)
+		return
+	}
+	ring = newRingBuffer(int(rate) / 2)
+	mic := &micInput{
+		device: device,
+		stream: &micStream{
+			ring:        ring,
+			buf:         make([]float32, scratchFrames*2),
+			maxBuffered: int(float64(rate)*micMaxLatency) * 2,
+		},
+		rate: rate,
+	}
+	if err := device.Start(); err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to start pass-through capture device: %v", err)
+		device.Uninit()
+		return
+	}
+
+	a.audioMu.Lock()
+	a.mic = mic
+	a.audioMu.Unlock()
+	a.applyVoicePreset()
+}
+
+func (a *App) stopVoice() {
+	a.audioMu.Lock()
+	done := a.auxBus.send(command{kind: cmdInput})
+	mic := a.mic
+	a.mic = nil
+	a.audioMu.Unlock()
+	a.await(done)
+
+	if mic != nil {
+		mic.device.Uninit()
+	}
+}
+
+// applyVoicePreset rebuilds the effect chain on the mic. The new chain is
+// swapped in at the next buffer; effect tails (reverb, echo) start from silence.
+func (a *App) applyVoicePreset() {
+	a.mu.Lock()
+	chain := a.presetEffects(a.Config.Voice.Preset)
+	quality := a.Config.Output.ResampleQuality
+	a.mu.Unlock()
+
+	a.audioMu.Lock()
+	defer a.audioMu.Unlock()
+	if a.mic == nil {
+		return
+	}
+	a.auxBus.send(command{kind: cmdInput, voice: &voice{id: "mic", streamer: buildEffectChain(a.mic.stream, chain, a.mic.rate, quality)}})
+}
+
+// SelectVoicePreset puts a preset on the mic ("" for none)
+func (a *App) SelectVoicePreset(id string) {
+	a.mu.Lock()
+	a.Config.Voice.Preset = id
+	a.saveConfig()
+	a.mu.Unlock()
+
+	a.applyVoicePreset()
+	runtime.EventsEmit(a.ctx, "voice-preset", id)
+}
+
+// toggleVoicePreset is used by the preset hotkeys: pressing the active preset's key turns it off
+func (a *App) toggleVoicePreset(id string) {
+	a.mu.Lock()
+	active := a.Config.Voice.Preset == id
+	a.mu.Unlock()
+
+	if active {
+		id = ""
+	}
+	a.SelectVoicePreset(id)
+}
+
+func (a *App) GetVoiceSettings() VoiceSettings {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.Config.Voice
+}
+
+// SetVoiceSettings reopens the pass-through only if the device or on/off state changed
+func (a *App) SetVoiceSettings(settings VoiceSettings) {
+	a.mu.Lock()
+	old := a.Config.Voice
+	a.Config.Voice = settings
+	a.saveConfig()
+	a.mu.Unlock()
+
+	if old.Enabled != settings.Enabled || old.Device != settings.Device {
+		a.restartVoice()
+	} else if old.Preset != settings.Preset {
+		a.applyVoicePreset()
+	}
+}
+
+func (a *App) GetEffectPresets() []EffectPreset {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	presets := make([]EffectPreset, len(a.Config.Presets))
+	for i, p := range a.Config.Presets {
+		presets[i] = *p
+	}
+	return presets
+}
+
+// GetEffectDefaults lists the effect types and their default parameters
+func (a *App) GetEffectDefaults() map[string]map[string]float64 {
+	return effectDefaults
+}
+
+// SaveEffectPreset adds a preset (empty ID) or replaces the one with the same ID
+func (a *App) SaveEffectPreset(preset EffectPreset) EffectPreset {
+	if strings.EqualFold(preset.Hotkey, "esc") || strings.EqualFold(preset.Hotkey, "escape") {
+		preset.Hotkey = ""
+	}
+	a.mu.Lock()
+	if preset.ID == "" {
+		preset.ID = fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	stored := preset
+	found := false
+	for i, p := range a.Config.Presets {
+		if p.ID == preset.ID {
+			a.Config.Presets[i] = &stored
+			found = true
+			break
+		}
+	}
+	if !found {
+		a.Config.Presets = append(a.Config.Presets, &stored)
+	}
+	active := a.Config.Voice.Preset == preset.ID
+	a.saveConfig()
+	a.mu.Unlock()
+
+	if active {
+		a.applyVoicePreset()
+	}
+	return preset
+}
+
+func (a *App) DeleteEffectPreset(id string) {
+	a.mu.Lock()
+	for i, p := range a.Config.Presets {
+		if p.ID == id {
+			a.Config.Presets = append(a.Config.Presets[:i], a.Config.Presets[i+1:]...)
+			break
+		}
+	}
+	active := a.Config.Voice.Preset == id
+	if active {
+		a.Config.Voice.Preset = ""
+	}
+	if a.Config.Voice.ClipPreset == id {
+		a.Config.Voice.ClipPreset = ""
+	}
+	a.saveConfig()
+	a.mu.Unlock()
+
+	if active {
+		a.applyVoicePreset()
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestSaveEffectPresetClearsEscape(t *testing.T) {
+	a := NewApp()
+	for _, key := range []string{"Esc", "escape", "ESCAPE"} {
+		if got := a.SaveEffectPreset(EffectPreset{Name: "p", Hotkey: key}); got.Hotkey != "" {
+			t.Errorf("preset saved with hotkey %q for %q, want none", got.Hotkey, key)
+		}
+	}
+	if got := a.SaveEffectPreset(EffectPreset{Name: "p", Hotkey: "Ctrl+E"}); got.Hotkey != "Ctrl+E" {
+		t.Errorf("preset saved with hotkey %q, want Ctrl+E", got.Hotkey)
+	}
+	for _, p := range a.Config.Presets {
+		if p.Hotkey == "Esc" || p.Hotkey == "escape" || p.Hotkey == "ESCAPE" {
+			t.Errorf("preset %s stored bound to %s", p.ID, p.Hotkey)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// portableMarkers are the file names that, next to the exe, switch on
+// portable mode: everything is then kept in a "data" folder beside the exe
+var portableMarkers = []string{"portable", "portable.txt"}
+
+// appDirs is where the app keeps its files
+type appDirs struct {
+	portable bool
+	exe      string // Folder holding the exe
+	data     string // config.json, library/ and logs/
+}
+
+// getAppDirs works the folders out once; the marker is only looked for at startup
+var getAppDirs = sync.OnceValue(func() appDirs {
+	var dirs appDirs
+	if exe, err := os.Executable(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		dirs.exe = filepath.Dir(exe)
+	} else {
+		dirs.exe, _ = os.Getwd()
+	}
+
+	for _, name := range portableMarkers {
+		if info, err := os.Stat(filepath.Join(dirs.exe, name)); err == nil && !info.IsDir() {
+			dirs.portable = true
+			break
+		}
+	}
+
+	if configDir, err := os.UserConfigDir(); err == nil && !dirs.portable {
+		dirs.data = filepath.Join(configDir, "daitoue")
+	} else {
+		dirs.data = filepath.Join(dirs.exe, "data")
+	}
+	return dirs
+})
+
+// logFile returns the log path used in portable mode
+func logFile() string {
+	dir := filepath.Join(getAppDirs().data, "logs")
+	os.MkdirAll(dir, 0755)
+	return filepath.Join(dir, "daitoue.log")
+}
+
+// libraryVar is the variable a stored clip path starts with when it is in
+// the library; any environment variable (%USERPROFILE%, %APPDATA%...) works too
+const libraryVar = "%LIBRARY%"
+
+var pathVarPattern = regexp.MustCompile(`%([A-Za-z_][A-Za-z0-9_]*)%`)
+
+// storePath is how a clip path is written to the config, so that it still
+// works when the library, the user profile or a drive letter moves. Clips in
+// the library (root, "" for none) are stored as %LIBRARY%\..., then in
+// portable mode on a system with drive letters files on the exe's drive are
+// stored relative to the exe, and files in the user's profile as
+// %USERPROFILE%\...
+func storePath(path, root string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	if rel, ok := pathWithin(root, path); ok {
+		return filepath.Join(libraryVar, rel)
+	}
+	if dirs := getAppDirs(); dirs.portable && filepath.VolumeName(dirs.exe) != "" &&
+		strings.EqualFold(filepath.VolumeName(path), filepath.VolumeName(dirs.exe)) {
+		if rel, err := filepath.Rel(dirs.exe, path); err == nil {
+			return rel
+		}
+	}
+	if rel, ok := pathWithin(os.Getenv("USERPROFILE"), path); ok {
+		return filepath.Join("%USERPROFILE%", rel)
+	}
+	return path
+}
+
+// resolvePath turns a stored clip path back into an absolute one: variables
+// are expanded, and what is still relative is taken as relative to the exe
+func resolvePath(path, root string) string {
+	if path == "" {
+		return path
+	}
+	path = expandPathVars(path, root)
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(getAppDirs().exe, path)
+}
+
+// expandPathVars replaces %LIBRARY% with root and %NAME% with the
+// environment variable NAME
+func expandPathVars(path, root string) string {
+	return pathVarPattern.ReplaceAllStringFunc(path, func(v string) string {
+		name := v[1 : len(v)-1]
+		if strings.EqualFold(v, libraryVar) {
+			if root == "" {
+				return v
+			}
+			return root
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		return v // Leave unknown variables for the user to spot
+	})
+}
+
+// pathWithin reports whether path is inside dir, and where
+func pathWithin(dir, path string) (string, bool) {
+	if dir == "" {
+		return "", false
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// libraryRoot is the folder %LIBRARY% stands for. Caller holds a.mu.
+func (a *App) libraryRoot() string {
+	if a.Config.LibraryRoot != "" {
+		return resolvePath(a.Config.LibraryRoot, "")
+	}
+	return defaultLibraryRoot()
+}
+
+func defaultLibraryRoot() string {
+	return filepath.Join(getAppDirs().data, "library")
+}
+
+// GetLibraryRoot returns the library folder
+func (a *App) GetLibraryRoot() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.libraryRoot()
+}
+
+// RelocateLibraryRoot asks where the library has been moved to and points
+// every clip that was in it there. Returns "" if cancelled.
+func (a *App) RelocateLibraryRoot() string {
+	dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Select Library Folder",
+	})
+	if err != nil || dir == "" {
+		return ""
+	}
+	missing := a.relocateLibraryRoot(dir)
+	runtime.EventsEmit(a.ctx, "audios-changed")
+	if missing > 0 {
+		return fmt.Sprintf("Error: 有 %d 个音频在新位置找不到", missing)
+	}
+	return "OK"
+}
+
+// relocateLibraryRoot makes root the library, rewriting the paths of all
+// clips under the old root in one go. Returns how many of them aren't there.
+func (a *App) relocateLibraryRoot(root string) int {
+	root = filepath.Clean(root)
+
+	a.mu.Lock()
+	old := a.libraryRoot()
+	missing := 0
+	for _, item := range a.Config.AudioList {
+		rel, ok := pathWithin(old, item.Path)
+		if !ok {
+			continue
+		}
+		item.Path = filepath.Join(root, rel)
+		if _, err := os.Stat(item.Path); err != nil {
+			missing++
+		}
+	}
+	if strings.EqualFold(root, defaultLibraryRoot()) {
+		a.Config.LibraryRoot = ""
+	} else {
+		a.Config.LibraryRoot = storePath(root, "")
+	}
+	a.saveConfig()
+	a.mu.Unlock()
+	return missing
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStorePathRoundTrip(t *testing.T) {
+	base := t.TempDir()
+	home := filepath.Join(base, "home")
+	root := filepath.Join(home, "library") // %LIBRARY% wins over %USERPROFILE%
+	exe := filepath.Join(base, "app")
+	t.Setenv("USERPROFILE", home)
+	withAppDirs(t, appDirs{portable: true, exe: exe, data: filepath.Join(exe, "data")})
+
+	for _, tc := range []struct {
+		name, path, stored string
+	}{
+		{"in the library", filepath.Join(root, "a", "b.wav"), filepath.Join(libraryVar, "a", "b.wav")},
+		{"in the profile", filepath.Join(home, "Music", "c.wav"), filepath.Join("%USERPROFILE%", "Music", "c.wav")},
+		{"elsewhere", filepath.Join(base, "other", "e.wav"), filepath.Join(base, "other", "e.wav")},
+		{"next to the exe", filepath.Join(exe, "clips", "f.wav"), filepath.Join(exe, "clips", "f.wav")}, // No drive letters here
+		{"relative", filepath.Join("clips", "g.wav"), filepath.Join("clips", "g.wav")},
+	} {
+		stored := storePath(tc.path, root)
+		if stored != tc.stored {
+			t.Errorf("%s: storePath(%s) = %s, want %s", tc.name, tc.path, stored, tc.stored)
+		}
+		want := tc.path
+		if !filepath.IsAbs(want) {
+			want = filepath.Join(exe, want)
+		}
+		if got := resolvePath(stored, root); got != want {
+			t.Errorf("%s: resolvePath(%s) = %s, want %s", tc.name, stored, got, want)
+		}
+	}
+
+	// Without a library root, %LIBRARY% isn't used
+	if got, want := storePath(filepath.Join(root, "a.wav"), ""), filepath.Join("%USERPROFILE%", "library", "a.wav"); got != want {
+		t.Errorf("storePath with no root = %s, want %s", got, want)
+	}
+}
+
+func TestExpandPathVars(t *testing.T) {
+	t.Setenv("USERPROFILE", "/home/u")
+	t.Setenv("DAITOUE_TEST_DIR", "/srv/clips")
+	for _, tc := range []struct {
+		path, root, want string
+	}{
+		{"%LIBRARY%/a.wav", "/lib", "/lib/a.wav"},
+		{"%library%/a.wav", "/lib", "/lib/a.wav"},
+		{"%LIBRARY%/a.wav", "", "%LIBRARY%/a.wav"},
+		{"%USERPROFILE%/Music/a.wav", "/lib", "/home/u/Music/a.wav"},
+		{"%DAITOUE_TEST_DIR%/a.wav", "/lib", "/srv/clips/a.wav"},
+		{"%DAITOUE_NOT_SET%/a.wav", "/lib", "%DAITOUE_NOT_SET%/a.wav"},
+		{"100%/a.wav", "/lib", "100%/a.wav"},
+	} {
+		if got := expandPathVars(tc.path, tc.root); got != tc.want {
+			t.Errorf("expandPathVars(%q, %q) = %q, want %q", tc.path, tc.root, got, tc.want)
+		}
+	}
+
+	// An unknown variable is left in place, relative to the exe
+	withAppDirs(t, appDirs{exe: "/opt/app"})
+	if got, want := resolvePath("%DAITOUE_NOT_SET%/a.wav", "/lib"), filepath.Join("/opt/app", "%DAITOUE_NOT_SET%", "a.wav"); got != want {
+		t.Errorf("resolvePath with an unknown variable = %s, want %s", got, want)
+	}
+}
+
+func TestRelocateLibraryRoot(t *testing.T) {
+	base := t.TempDir()
+	home := filepath.Join(base, "home")
+	t.Setenv("USERPROFILE", home)
+	withAppDirs(t, appDirs{exe: filepath.Join(base, "app"), data: filepath.Join(base, "data")})
+	old := defaultLibraryRoot()
+	moved := filepath.Join(home, "moved")
+	writeFile(t, filepath.Join(moved, "a", "here.wav"), "")
+	outside := filepath.Join(base, "other", "c.wav")
+
+	a := NewApp()
+	a.Config.AudioList = []*AudioItem{
+		{ID: "1", Path: filepath.Join(old, "a", "here.wav")},
+		{ID: "2", Path: filepath.Join(old, "gone.wav")},
+		{ID: "3", Path: outside},
+	}
+	if missing := a.relocateLibraryRoot(moved); missing != 1 {
+		t.Errorf("%d clips missing after the move, want 1", missing)
+	}
+	for i, want := range []string{filepath.Join(moved, "a", "here.wav"), filepath.Join(moved, "gone.wav"), outside} {
+		if got := a.Config.AudioList[i].Path; got != want {
+			t.Errorf("clip %d at %s, want %s", i+1, got, want)
+		}
+	}
+	if want := filepath.Join("%USERPROFILE%", "moved"); a.Config.LibraryRoot != want {
+		t.Errorf("LibraryRoot stored as %s, want %s", a.Config.LibraryRoot, want)
+	}
+	if got := a.libraryRoot(); got != moved {
+		t.Errorf("libraryRoot = %s, want %s", got, moved)
+	}
+	if got := a.configForDisk().AudioList[0].Path; got != filepath.Join(libraryVar, "a", "here.wav") {
+		t.Errorf("moved clip stored as %s", got)
+	}
+
+	// Moving it back to the default forgets the setting
+	a.relocateLibraryRoot(old)
+	if a.Config.LibraryRoot != "" || a.Config.AudioList[0].Path != filepath.Join(old, "a", "here.wav") {
+		t.Errorf("after moving back LibraryRoot = %q and clip 1 at %s", a.Config.LibraryRoot, a.Config.AudioList[0].Path)
+	}
+}
+
+func TestRecordingFolderRoundTrip(t *testing.T) {
+	base := t.TempDir()
+	home := filepath.Join(base, "home")
+	t.Setenv("USERPROFILE", home)
+	data := filepath.Join(base, "app", "data")
+	withAppDirs(t, appDirs{portable: true, exe: filepath.Join(base, "app"), data: data})
+	if got, want := defaultRecordingDir(), filepath.Join(data, "recordings"); got != want {
+		t.Errorf("defaultRecordingDir in portable mode = %s, want %s", got, want)
+	}
+
+	folder := filepath.Join(home, "Recordings")
+	a := NewApp()
+	a.mu.Lock()
+	a.Config.Recording.Folder = folder
+	if got, want := a.configForDisk().Recording.Folder, filepath.Join("%USERPROFILE%", "Recordings"); got != want {
+		t.Errorf("recording folder stored as %s, want %s", got, want)
+	}
+	a.saveConfig()
+	a.mu.Unlock()
+	a.flushConfig()
+
+	// The profile moves, as it does when the drive letter changes
+	home = filepath.Join(base, "elsewhere")
+	t.Setenv("USERPROFILE", home)
+	b := NewApp()
+	b.loadConfig()
+	if got, want := b.Config.Recording.Folder, filepath.Join(home, "Recordings"); got != want {
+		t.Errorf("recording folder loaded as %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// The app ships for Windows only; these let it build and run headless
+// elsewhere (CI with the null audio backend).
+
+func acquireInstance() (release func(), ok bool) {
+	return func() {}, true
+}
+
+func messageBox(title, text string) {
+	fmt.Fprintf(os.Stderr, "%s: %s\n", title, text)
+}
+
+func hideWindow(cmd *exec.Cmd) {}
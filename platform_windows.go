@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32        = syscall.NewLazyDLL("kernel32.dll")
+	user32          = syscall.NewLazyDLL("user32.dll")
+	procCreateMutex = kernel32.NewProc("CreateMutexW")
+	procCloseHandle = kernel32.NewProc("CloseHandle")
+	procMessageBox  = user32.NewProc("MessageBoxW")
+)
+
+func createMutex(name string) (uintptr, error) {
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+
+	ret, _, err := procCreateMutex.Call(0, 0, uintptr(unsafe.Pointer(namePtr)))
+
+	if err == syscall.ERROR_ALREADY_EXISTS || err == syscall.ERROR_ACCESS_DENIED {
+		return ret, syscall.ERROR_ALREADY_EXISTS // Normalize to ALREADY_EXISTS for caller
+	}
+
+	return ret, nil
+}
+
+// acquireInstance takes the system-wide mutex that keeps a second copy from
+// starting. ok is false if another copy already holds it.
+func acquireInstance() (release func(), ok bool) {
+	handle, err := createMutex("Global\\DaitoueAppMutex")
+	if err == syscall.ERROR_ALREADY_EXISTS {
+		return nil, false
+	}
+	// 程序退出时释放句柄（虽然系统会自动回收，但显式释放是好习惯）
+	return func() { procCloseHandle.Call(handle) }, true
+}
+
+func messageBox(title, text string) {
+	titlePtr, _ := syscall.UTF16PtrFromString(title)
+	textPtr, _ := syscall.UTF16PtrFromString(text)
+	procMessageBox.Call(0, uintptr(unsafe.Pointer(textPtr)), uintptr(unsafe.Pointer(titlePtr)), 0x40|0x1000)
+}
+
+// hideWindow keeps a console child process (the update script) from showing a window
+func hideWindow(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// RecordingSettings controls the output recorder
+type RecordingSettings struct {
+	Folder string `json:"folder"` // "" = defaultRecordingDir()
+	Format string `json:"format"` // "wav" or "flac"
+	Bus    string `json:"bus"`    // "aux" (what the virtual mic hears) or "main"
+}
+
+// RecordingStatus is reported to the frontend
+type RecordingStatus struct {
+	Active  bool    `json:"active"`
+	Path    string  `json:"path"`
+	Seconds float64 `json:"seconds"`
+	Dropped uint64  `json:"dropped"` // Frames lost because the writer fell behind
+	Error   string  `json:"error"`
+}
+
+// recorderBufferSeconds is how far the file writer may fall behind the audio thread
+const recorderBufferSeconds = 2
+
+// recorder taps a bus's mixed output and writes it to disk on its own goroutine
+type recorder struct {
+	bus    *bus
+	ring   *ringBuffer
+	writer audioWriter
+	path   string
+	rate   int
+	frames atomic.Uint64 // Frames written to the file
+	stop   chan struct{}
+	done   chan error
+}
+
+// defaultRecordingDir is where recordings go unless a folder is chosen: the
+// user's music folder, or in portable mode the data folder beside the exe
+func defaultRecordingDir() string {
+	if dirs := getAppDirs(); dirs.portable {
+		return filepath.Join(dirs.data, "recordings")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "daitoue")
+	}
+	return filepath.Join(home, "Music", "呆头鹅")
+}
+
+func (r *recorder) run() {
+	buf := make([]float32, 8192)
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	var err error
+	for {
+		select {
+		case <-r.stop:
+			if derr := r.drain(buf); err == nil {
+				err = derr
+			}
+			if cerr := r.writer.Close(); err == nil {
+				err = cerr
+			}
+			r.done <- err
+			return
+		case <-ticker.C:
+			if derr := r.drain(buf); err == nil {
+				err = derr
+			}
+		}
+	}
+}
+
+// drain moves everything queued by the audio thread into the file.
+// After a write error it keeps emptying the ring so the tap doesn't overrun.
+func (r *recorder) drain(buf []float32) error {
+	var err error
+	for {
+		n := r.ring.read(buf)
+		if n == 0 {
+			return err
+		}
+		if err == nil {
+			err = r.writer.WriteSamples(buf[:n])
+			r.frames.Add(uint64(n / 2))
+		}
+	}
+}
+
+func (r *recorder) status() RecordingStatus {
+	return RecordingStatus{
+		Active:  true,
+		Path:    r.path,
+		Seconds: float64(r.frames.Load()) / float64(r.rate),
+		Dropped: r.ring.dropped.Load(),
+	}
+}
+
+// StartRecording starts writing the mixed output of the configured bus to a new file
+func (a *App) StartRecording() RecordingStatus {
+	a.mu.Lock()
+	settings := a.Config.Recording
+	a.mu.Unlock()
+
+	a.audioMu.Lock()
+	if a.recorder != nil {
+		status := a.recorder.status()
+		a.audioMu.Unlock()
+		return status
+	}
+
+	b := a.auxBus
+	if settings.Bus == "main" {
+		b = a.mainBus
+	}
+	if b.device == nil {
+		a.audioMu.Unlock()
+		return RecordingStatus{Error: "要录制的播放设备未启用"}
+	}
+	rate := int(b.format.sampleRate)
+
+	dir := settings.Folder
+	if dir == "" {
+		dir = defaultRecordingDir()
+	}
+	ext := "wav"
+	if settings.Format == "flac" {
+		ext = "flac"
+	}
+	path := filepath.Join(dir, "呆头鹅_"+time.Now().Format("20060102_150405")+"."+ext)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		a.audioMu.Unlock()
+		return RecordingStatus{Error: "无法创建录音文件夹: " + err.Error()}
+	}
+	w, err := newAudioWriter(path, ext, rate, 2)
+	if err != nil {
+		a.audioMu.Unlock()
+		return RecordingStatus{Error: "无法创建录音文件: " + err.Error()}
+	}
+
+	r := &recorder{
+		bus:    b,
+		ring:   newRingBuffer(rate * recorderBufferSeconds),
+		writer: w,
+		path:   path,
+		rate:   rate,
+		stop:   make(chan struct{}),
+		done:   make(chan error, 1),
+	}
+	go r.run()
+	b.tap.Store(r.ring)
+	a.recorder = r
+	status := r.status()
+	a.audioMu.Unlock()
+
+	a.setTrayRecording(true)
+	runtime.EventsEmit(a.ctx, "recording-status", status)
+	return status
+}
+
+// StopRecording finishes the current recording and returns its final status
+func (a *App) StopRecording() RecordingStatus {
+	a.audioMu.Lock()
+	r := a.recorder
+	a.recorder = nil
+	a.audioMu.Unlock()
+
+	if r == nil {
+		return RecordingStatus{}
+	}
+
+	r.bus.tap.Store(nil)
+	close(r.stop)
+	err := <-r.done
+
+	status := r.status()
+	status.Active = false
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Recording %s failed: %v", r.path, err)
+		status.Error = "录音写入失败: " + err.Error()
+	}
+
+	a.setTrayRecording(false)
+	runtime.EventsEmit(a.ctx, "recording-status", status)
+	return status
+}
+
+// ToggleRecording starts or stops recording (used by the tray menu)
+func (a *App) ToggleRecording() RecordingStatus {
+	a.audioMu.Lock()
+	active := a.recorder != nil
+	a.audioMu.Unlock()
+
+	if active {
+		return a.StopRecording()
+	}
+	return a.StartRecording()
+}
+
+func (a *App) GetRecordingStatus() RecordingStatus {
+	a.audioMu.Lock()
+	defer a.audioMu.Unlock()
+	if a.recorder == nil {
+		return RecordingStatus{}
+	}
+	return a.recorder.status()
+}
+
+func (a *App) GetRecordingSettings() RecordingSettings {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.Config.Recording
+}
+
+// SetRecordingSettings applies to the next recording
+func (a *App) SetRecordingSettings(settings RecordingSettings) {
+	if settings.Format != "flac" {
+		settings.Format = "wav"
+	}
+	if settings.Bus != "main" {
+		settings.Bus = "aux"
+	}
+
+	a.mu.Lock()
+	a.Config.Recording = settings
+	a.saveConfig()
+	a.mu.Unlock()
+}
+
+// ChooseRecordingFolder lets the user pick the output folder, returning "" if cancelled
+func (a *App) ChooseRecordingFolder() string {
+	dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Select Recording Folder",
+	})
+	if err != nil || dir == "" {
+		return ""
+	}
+
+	a.mu.Lock()
+	a.Config.Recording.Folder = dir
+	a.saveConfig()
+	a.mu.Unlock()
+	return dir
+}
+
+// setTrayRecording keeps the tray checkbox in sync with the recorder
+func (a *App) setTrayRecording(active bool) {
+	if a.trayRecord == nil {
+		return
+	}
+	if active {
+		a.trayRecord.Check()
+	} else {
+		a.trayRecord.Uncheck()
+	}
+}
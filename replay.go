@@ -0,0 +1,194 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gen2brain/malgo"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ReplaySettings configures the instant-replay buffer
+type ReplaySettings struct {
+	Enabled bool   `json:"enabled"`
+	Source  string `json:"source"`  // "mic" or "loopback"
+	Device  string `json:"device"`  // Capture device ID (mic) or playback device ID (loopback), "" = default
+	Seconds int    `json:"seconds"` // How much to keep and save
+	Hotkey  string `json:"hotkey"`  // Saves the buffer as a new clip
+}
+
+const defaultReplaySeconds = 30
+
+// replayBuffer keeps the last few seconds of a capture device, overwriting
+// the oldest samples. Like ringBuffer, the capture callback never blocks:
+// while a snapshot is being copied out the oldest sample it copies is pinned,
+// and if the callback would have to overwrite it, new samples are dropped
+// and counted instead. The ring keeps a second of slack past what is saved so
+// that in practice it never comes to that.
+type replayBuffer struct {
+	buf     []float32 // Interleaved stereo
+	mask    uint64
+	keep    uint64        // Samples a snapshot holds at most
+	head    atomic.Uint64 // Samples written so far
+	pin     atomic.Uint64 // First sample a snapshot is copying, or unpinned
+	dropped atomic.Uint64 // Frames lost to a snapshot
+	rate    int
+
+	snapMu sync.Mutex // Serializes snapshots; never taken by the callback
+}
+
+const unpinned = math.MaxUint64
+
+// newReplayBuffer keeps seconds of audio, or as much of it as the import
+// limits let a saved replay have
+func newReplayBuffer(rate, seconds int, limits ImportSettings) *replayBuffer {
+	frames := min(rate*seconds, limits.maxCaptureFrames(rate))
+	size := uint64(1)
+	for size < uint64((frames+rate)*captureChannels) {
+		size <<= 1
+	}
+	r := &replayBuffer{buf: make([]float32, size), mask: size - 1, keep: uint64(frames * captureChannels), rate: rate}
+	r.pin.Store(unpinned)
+	return r
+}
+
+// write is the capture data callback
+func (r *replayBuffer) write(pInput []byte, framecount uint32) {
+	head := r.head.Load()
+	count := uint64(framecount) * captureChannels
+	if pin := r.pin.Load(); pin != unpinned {
+		free := uint64(0)
+		if limit := pin + uint64(len(r.buf)); limit > head {
+			free = (limit - head) / captureChannels * captureChannels
+		}
+		if count > free {
+			r.dropped.Add((count - free) / captureChannels)
+			count = free
+		}
+	}
+	for i := uint64(0); i < count; i++ {
+		r.buf[(head+i)&r.mask] = getSample(pInput[i*4:])
+	}
+	r.head.Store(head + count)
+}
+
+// snapshot copies out everything currently buffered, oldest first. A
+// callback already running when the pin is set doesn't see it, but it only
+// writes one period, which the slack has room for many times over.
+func (r *replayBuffer) snapshot() []float32 {
+	r.snapMu.Lock()
+	defer r.snapMu.Unlock()
+
+	head := r.head.Load()
+	n := min(head, r.keep)
+	start := head - n
+	r.pin.Store(start)
+	defer r.pin.Store(unpinned)
+
+	out := make([]float32, n)
+	copied := copy(out, r.buf[start&r.mask:])
+	copy(out[copied:], r.buf[:n-uint64(copied)])
+	return out
+}
+
+// normalize fills in defaults and clamps the settings, the length to what
+// the import limits let a saved replay have
+func (s ReplaySettings) normalize(limits ImportSettings) ReplaySettings {
+	if s.Seconds <= 0 {
+		s.Seconds = defaultReplaySeconds
+	}
+	s.Seconds = max(min(s.Seconds, int(limits.maxCaptureSeconds())), 1)
+	if s.Source != "loopback" {
+		s.Source = "mic"
+	}
+	if strings.EqualFold(s.Hotkey, "esc") || strings.EqualFold(s.Hotkey, "escape") {
+		s.Hotkey = ""
+	}
+	return s
+}
+
+// restartReplay (re)opens the capture device for the replay buffer if enabled
+func (a *App) restartReplay() {
+	a.stopReplay()
+
+	a.mu.Lock()
+	settings, limits := a.Config.Replay, a.Config.Import
+	a.mu.Unlock()
+
+	if !settings.Enabled || a.malCtx == nil {
+		return
+	}
+
+	kind := malgo.DeviceType(malgo.Capture)
+	if settings.Source == "loopback" {
+		kind = malgo.Loopback
+	}
+
+	var rb *replayBuffer
+	device, rate, err := a.openCapture(kind, settings.Device, 0, func(pInput []byte, framecount uint32) {
+		rb.write(pInput, framecount)
+	})
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to open replay capture device: %v", err)
+		return
+	}
+	rb = newReplayBuffer(rate, settings.Seconds, limits)
+	if err := device.Start(); err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to start replay capture device: %v", err)
+		device.Uninit()
+		return
+	}
+
+	a.audioMu.Lock()
+	a.replayDevice = device
+	a.replay = rb
+	a.audioMu.Unlock()
+}
+
+func (a *App) stopReplay() {
+	a.audioMu.Lock()
+	device := a.replayDevice
+	a.replayDevice = nil
+	a.replay = nil
+	a.audioMu.Unlock()
+
+	if device != nil {
+		device.Uninit()
+	}
+}
+
+// SaveReplay writes the replay buffer to a WAV in the library and imports it as a new clip
+func (a *App) SaveReplay() ImportEntry {
+	a.audioMu.Lock()
+	rb := a.replay
+	a.audioMu.Unlock()
+
+	if rb == nil {
+		return captureFailed(reasonNotCapturing, "即时回放未开启")
+	}
+
+	samples := rb.snapshot()
+	if len(samples) == 0 {
+		return captureFailed(reasonNoAudio, "回放缓冲区为空")
+	}
+	return a.saveCapture("回放_"+time.Now().Format("20060102_150405")+".wav", samples, rb.rate)
+}
+
+func (a *App) GetReplaySettings() ReplaySettings {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.Config.Replay
+}
+
+// SetReplaySettings stores the settings and reopens the capture device
+func (a *App) SetReplaySettings(settings ReplaySettings) {
+	a.mu.Lock()
+	a.Config.Replay = settings.normalize(a.Config.Import)
+	a.saveConfig()
+	a.mu.Unlock()
+
+	a.restartReplay()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"sync/atomic"
+	"testing"
+)
+
+// rampPeriod is one capture period whose samples count up from first, so a
+// snapshot shows whether anything was lost or overwritten
+func rampPeriod(p []byte, first uint64) {
+	for i := range len(p) / 4 {
+		binary.LittleEndian.PutUint32(p[i*4:], math.Float32bits(float32(first+uint64(i))))
+	}
+}
+
+// checkRamp fails unless out counts up by one
+func checkRamp(t *testing.T, out []float32) {
+	t.Helper()
+	for i := 1; i < len(out); i++ {
+		if out[i] != out[i-1]+1 {
+			t.Fatalf("snapshot jumps from %v to %v at %d", out[i-1], out[i], i)
+		}
+	}
+}
+
+func TestReplaySnapshotDuringCapture(t *testing.T) {
+	const rate, frames = 1000, 64
+	r := newReplayBuffer(rate, 2, ImportSettings{})
+	period := make([]byte, frames*captureChannels*4)
+
+	var written atomic.Uint64
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() { // The capture callback
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			rampPeriod(period, r.head.Load())
+			r.write(period, frames)
+			written.Add(1)
+		}
+	}()
+
+	// Until the ring has wrapped many times over
+	for written.Load() < 1000 {
+		out := r.snapshot()
+		if uint64(len(out)) > r.keep {
+			t.Fatalf("snapshot of %d samples, want at most %d", len(out), r.keep)
+		}
+		checkRamp(t, out)
+	}
+	close(stop)
+	<-done
+
+	head := r.head.Load()
+	out := r.snapshot()
+	if uint64(len(out)) != r.keep {
+		t.Errorf("snapshot of %d samples once full, want %d", len(out), r.keep)
+	}
+	checkRamp(t, out)
+	if last := out[len(out)-1]; uint64(last) != head-1 {
+		t.Errorf("snapshot ends at %v, want the latest sample %d", last, head-1)
+	}
+}
+
+func TestReplayPinDropsInsteadOfOverwriting(t *testing.T) {
+	const rate, frames = 1000, 100
+	r := newReplayBuffer(rate, 1, ImportSettings{})
+	period := make([]byte, frames*captureChannels*4)
+	write := func() {
+		rampPeriod(period, r.head.Load())
+		r.write(period, frames)
+	}
+	for range 10 {
+		write()
+	}
+
+	// With a snapshot stuck copying from the start, the callback can go on
+	// until the ring is full but not past it
+	r.pin.Store(0)
+	for range len(r.buf) / (frames * captureChannels) {
+		write()
+	}
+	if got := r.head.Load(); got != uint64(len(r.buf)) {
+		t.Errorf("head = %d with the start pinned, want the ring's size %d", got, len(r.buf))
+	}
+	if r.dropped.Load() == 0 {
+		t.Error("nothing counted as dropped")
+	}
+	if r.buf[0] != 0 || r.buf[1] != 1 {
+		t.Errorf("pinned samples overwritten with %v", r.buf[:2])
+	}
+
+	r.pin.Store(unpinned)
+	write()
+	if r.head.Load() != uint64(len(r.buf))+frames*captureChannels {
+		t.Error("callback still held back after the snapshot let go")
+	}
+	checkRamp(t, r.snapshot())
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"sync/atomic"
+)
+
+// ringBuffer is a single-producer single-consumer queue of interleaved stereo
+// float32 samples. The producer is the audio thread, so writes never block or
+// allocate: if the consumer falls behind, new samples are dropped and counted.
+type ringBuffer struct {
+	buf     []float32
+	mask    uint64
+	head    atomic.Uint64 // Next sample index to write
+	tail    atomic.Uint64 // Next sample index to read
+	dropped atomic.Uint64 // Frames lost to overruns
+}
+
+// newRingBuffer allocates room for at least frames stereo frames
+func newRingBuffer(frames int) *ringBuffer {
+	size := uint64(1)
+	for size < uint64(frames*2) {
+		size <<= 1
+	}
+	return &ringBuffer{buf: make([]float32, size), mask: size - 1}
+}
+
+// write appends stereo frames. Called from the audio thread only.
+func (r *ringBuffer) write(samples [][2]float64) {
+	head, tail := r.head.Load(), r.tail.Load()
+	free := uint64(len(r.buf)) - (head - tail)
+	n := uint64(len(samples))
+	if n*2 > free {
+		r.dropped.Add(n - free/2)
+		n = free / 2
+	}
+	for i := uint64(0); i < n; i++ {
+		r.buf[(head+i*2)&r.mask] = float32(samples[i][0])
+		r.buf[(head+i*2+1)&r.mask] = float32(samples[i][1])
+	}
+	r.head.Store(head + n*2)
+}
+
+// writeF32 appends frames of interleaved stereo F32 bytes as delivered by a
+// capture device. Called from the audio thread only.
+func (r *ringBuffer) writeF32(p []byte, frames int) {
+	head, tail := r.head.Load(), r.tail.Load()
+	free := uint64(len(r.buf)) - (head - tail)
+	count := uint64(frames) * 2
+	if count > free {
+		r.dropped.Add((count - free) / 2)
+		count = free &^ 1
+	}
+	for i := uint64(0); i < count; i++ {
+		r.buf[(head+i)&r.mask] = getSample(p[i*4:])
+	}
+	r.head.Store(head + count)
+}
+
+// writeSilence appends n silent frames. Called from the audio thread only.
+func (r *ringBuffer) writeSilence(n int) {
+	head, tail := r.head.Load(), r.tail.Load()
+	free := uint64(len(r.buf)) - (head - tail)
+	count := uint64(n) * 2
+	if count > free {
+		r.dropped.Add((count - free) / 2)
+		count = free &^ 1
+	}
+	for i := uint64(0); i < count; i++ {
+		r.buf[(head+i)&r.mask] = 0
+	}
+	r.head.Store(head + count)
+}
+
+// read copies up to len(p) samples (always whole frames) into p and returns the count
+func (r *ringBuffer) read(p []float32) int {
+	head, tail := r.head.Load(), r.tail.Load()
+	n := min(uint64(len(p))&^1, head-tail)
+	for i := uint64(0); i < n; i++ {
+		p[i] = r.buf[(tail+i)&r.mask]
+	}
+	r.tail.Store(tail + n)
+	return int(n)
+}
+
+// buffered returns how many samples are waiting. Called from the consumer only.
+func (r *ringBuffer) buffered() int {
+	return int(r.head.Load() - r.tail.Load())
+}
+
+// discard drops up to n samples (whole frames) from the read side. Called from the consumer only.
+func (r *ringBuffer) discard(n int) {
+	head, tail := r.head.Load(), r.tail.Load()
+	r.tail.Store(tail + min(uint64(n)&^1, head-tail))
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+const (
+	streamBuffer       = 500 * time.Millisecond // Decoded audio kept ready ahead of a streamed clip
+	streamChunkFrames  = 2048                   // Decoded per step
+	streamPollInterval = 5 * time.Millisecond   // How often the reader tops up and looks for seeks
+)
+
+// streamReader plays a clip streamed from disk without touching the disk on
+// the audio thread. A reader goroutine decodes ahead into a ring, which the
+// audio thread only reads; seeks are handed to the reader, and the clip
+// stays silent until the first audio from the new position is in.
+type streamReader struct {
+	dec    beep.StreamSeekCloser
+	ring   *ringBuffer
+	length int
+
+	// Seeks: the audio thread bumps seekGen after setting seekTo. The reader
+	// seeks and then publishes, in this order, where in the ring the new
+	// position starts, whether it has reached the end, and the seek it did.
+	seekTo    atomic.Int64
+	seekGen   atomic.Uint64
+	ackGen    atomic.Uint64
+	seekStart atomic.Uint64 // Ring sample index the audio from ackGen starts at
+	eof       atomic.Bool   // Everything up to the end is in the ring
+	err       atomic.Pointer[error]
+
+	// Audio thread only
+	gen     uint64 // Seek the ring is in step with
+	pos     int    // Frames into the clip, as far as has been handed out
+	scratch []float32
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newStreamReader decodes the start of dec and starts the reader. The
+// reader owns dec from then on and closes it along with itself.
+func newStreamReader(dec beep.StreamSeekCloser, format beep.Format) *streamReader {
+	frames := max(format.SampleRate.N(streamBuffer), streamChunkFrames*2)
+	r := &streamReader{
+		dec:     dec,
+		ring:    newRingBuffer(frames),
+		length:  dec.Len(),
+		scratch: make([]float32, scratchFrames*2),
+		stop:    make(chan struct{}),
+	}
+	buf := make([][2]float64, streamChunkFrames)
+	r.fill(buf) // So the clip starts without a gap
+	r.wg.Add(1)
+	go r.run(buf)
+	return r
+}
+
+// run keeps the ring topped up and carries out seeks until Close
+func (r *streamReader) run(buf [][2]float64) {
+	defer r.wg.Done()
+	ticker := time.NewTicker(streamPollInterval)
+	defer ticker.Stop()
+	for {
+		if gen := r.seekGen.Load(); gen != r.ackGen.Load() {
+			if err := r.dec.Seek(int(r.seekTo.Load())); err != nil {
+				r.err.Store(&err)
+			}
+			r.seekStart.Store(r.ring.head.Load())
+			r.eof.Store(false)
+			r.ackGen.Store(gen)
+		}
+		r.fill(buf)
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fill decodes until the ring is full or the clip ends. Reader only.
+func (r *streamReader) fill(buf [][2]float64) {
+	for !r.eof.Load() {
+		free := (len(r.ring.buf) - r.ring.buffered()) / 2
+		if free < len(buf) {
+			return
+		}
+		n, ok := r.dec.Stream(buf)
+		r.ring.write(buf[:n])
+		if !ok || n < len(buf) {
+			if err := r.dec.Err(); err != nil {
+				r.err.Store(&err)
+			}
+			r.eof.Store(true)
+		}
+		if r.seekGen.Load() != r.ackGen.Load() {
+			return // Don't decode further from a position about to be left
+		}
+	}
+}
+
+// Stream hands out what the reader has decoded. Running short is played as
+// silence rather than ending the clip. Audio thread only.
+func (r *streamReader) Stream(samples [][2]float64) (n int, ok bool) {
+	if r.gen != r.seekGen.Load() {
+		if r.ackGen.Load() != r.seekGen.Load() {
+			clear(samples) // The reader hasn't got there yet
+			return len(samples), true
+		}
+		r.gen = r.ackGen.Load()
+		r.pos = int(r.seekTo.Load())
+		r.ring.discard(int(r.seekStart.Load() - r.ring.tail.Load()))
+	}
+
+	eof := r.eof.Load() // Before reading, so nothing is written after it
+	for n < len(samples) {
+		want := min(len(samples)-n, len(r.scratch)/2)
+		got := r.ring.read(r.scratch[:want*2]) / 2
+		for i := range got {
+			samples[n+i] = [2]float64{float64(r.scratch[i*2]), float64(r.scratch[i*2+1])}
+		}
+		n += got
+		r.pos += got
+		if got < want {
+			break
+		}
+	}
+	if n < len(samples) && !eof {
+		clear(samples[n:])
+		return len(samples), true
+	}
+	return n, n > 0
+}
+
+func (r *streamReader) Err() error {
+	if err := r.err.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+func (r *streamReader) Len() int {
+	return r.length
+}
+
+func (r *streamReader) Position() int {
+	return r.pos
+}
+
+// Seek asks the reader to move to p. Audio thread only.
+func (r *streamReader) Seek(p int) error {
+	r.seekTo.Store(int64(p))
+	r.seekGen.Add(1)
+	return nil
+}
+
+// Close stops the reader and closes the decoder. Not on the audio thread.
+func (r *streamReader) Close() error {
+	close(r.stop)
+	r.wg.Wait()
+	return r.dec.Close()
+}
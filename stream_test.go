@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// rampClip is a clip whose every sample is its own frame number, so where a
+// read came from can be told from what it returned
+type rampClip struct {
+	pos, len int
+}
+
+func (c *rampClip) Stream(samples [][2]float64) (int, bool) {
+	n := min(len(samples), c.len-c.pos)
+	for i := range n {
+		samples[i] = [2]float64{float64(c.pos + i), float64(c.pos + i)}
+	}
+	c.pos += n
+	return n, n > 0
+}
+
+func (c *rampClip) Err() error       { return nil }
+func (c *rampClip) Len() int         { return c.len }
+func (c *rampClip) Position() int    { return c.pos }
+func (c *rampClip) Seek(p int) error { c.pos = p; return nil }
+func (c *rampClip) Close() error     { return nil }
+
+// streamUntil reads from r until it returns audio or ends, as the audio thread would
+func streamUntil(t *testing.T, r *streamReader, buf [][2]float64) (int, bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		n, ok := r.Stream(buf)
+		if !ok || buf[0] != [2]float64{} || time.Now().After(deadline) {
+			return n, ok
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStreamReaderSeek(t *testing.T) {
+	const rate = 1000 // Keep the ring small next to the clip
+	r := newStreamReader(&rampClip{len: 100000}, beep.Format{SampleRate: rate, NumChannels: 2, Precision: 4})
+	defer r.Close()
+
+	buf := make([][2]float64, 256)
+	if n, ok := r.Stream(buf); n != len(buf) || !ok || buf[1][0] != 1 || buf[255][0] != 255 {
+		t.Fatalf("first read = %d, %v, starting %v: want the clip's start at once", n, ok, buf[:2])
+	}
+
+	r.Seek(50000)
+	if got := r.Position(); got != 256 {
+		t.Errorf("Position = %d right after Seek, want 256 until the reader has moved", got)
+	}
+	streamUntil(t, r, buf)
+	if buf[0][0] != 50000 || buf[255][0] != 50255 {
+		t.Fatalf("read after seeking to 50000 starts at %v and ends at %v", buf[0][0], buf[255][0])
+	}
+	if got := r.Position(); got != 50256 {
+		t.Errorf("Position = %d, want 50256", got)
+	}
+
+	// Near the end the clip runs out rather than playing silence
+	r.Seek(100000 - 100)
+	if n, _ := streamUntil(t, r, buf); n < 100 || buf[0][0] != 100000-100 || buf[99][0] != 100000-1 {
+		t.Fatalf("read at the end = %d frames from %v to %v, want the last 100", n, buf[0][0], buf[99][0])
+	}
+	if !eventually(func() bool { n, ok := r.Stream(buf); return n == 0 && !ok }) {
+		t.Error("clip doesn't end")
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// PlaybackSettings decide what happens when a clip starts while another is playing
+type PlaybackSettings struct {
+	Mode      string  `json:"mode"`      // "cut", "crossfade" or "queue"
+	Crossfade float64 `json:"crossfade"` // Milliseconds
+	FadeOut   float64 `json:"fade_out"`  // Milliseconds taken to stop a clip, 0 = cut
+}
+
+// Transition modes
+const (
+	transitionCut       = "cut"       // Stop the current clip at once
+	transitionCrossfade = "crossfade" // Fade the current clip out while the new one fades in
+	transitionQueue     = "queue"     // Start once the current clip has finished
+)
+
+const (
+	defaultCrossfade = 300.0  // Milliseconds
+	maxCrossfade     = 5000.0 // Milliseconds
+	defaultFadeOut   = 30.0   // Milliseconds, just enough to avoid a click
+	maxFadeOut       = 2000.0 // Milliseconds
+)
+
+// transition is how one clip start treats whatever is already playing
+type transition struct {
+	mode string
+	fade time.Duration
+}
+
+// normalizeTransition falls back to def for an unknown mode and clamps the fade
+func normalizeTransition(mode string, crossfade float64, def string) (string, float64) {
+	switch mode {
+	case transitionCut, transitionCrossfade, transitionQueue:
+	default:
+		mode = def
+	}
+	return mode, max(0, min(crossfade, maxCrossfade))
+}
+
+// transitionFor combines a clip's own settings with the global ones. Caller holds a.mu.
+func (a *App) transitionFor(item *AudioItem) transition {
+	mode, fade := a.Config.Playback.Mode, a.Config.Playback.Crossfade
+	if item.Mode != "" {
+		mode = item.Mode
+	}
+	if item.Crossfade > 0 {
+		fade = item.Crossfade
+	}
+	return transition{mode: mode, fade: time.Duration(fade * float64(time.Millisecond))}
+}
+
+// frames converts the fade length to frames at rate
+func (t transition) frames(rate beep.SampleRate) int {
+	return rate.N(t.fade)
+}
+
+func (a *App) GetPlaybackSettings() PlaybackSettings {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.Config.Playback
+}
+
+func (a *App) SetPlaybackSettings(settings PlaybackSettings) {
+	settings.Mode, settings.Crossfade = normalizeTransition(settings.Mode, settings.Crossfade, transitionCut)
+	settings.FadeOut = max(0, min(settings.FadeOut, maxFadeOut))
+
+	a.mu.Lock()
+	a.Config.Playback = settings
+	a.saveConfig()
+	a.mu.Unlock()
+}
+
+// SetAudioTransition overrides the transition for one clip. An empty mode or
+// zero crossfade means "use the global setting".
+func (a *App) SetAudioTransition(id, mode string, crossfade float64) {
+	mode, crossfade = normalizeTransition(strings.ToLower(mode), crossfade, "")
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, item := range a.Config.AudioList {
+		if item.ID == id {
+			item.Mode = mode
+			item.Crossfade = crossfade
+			break
+		}
+	}
+	a.saveConfig()
+}
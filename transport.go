@@ -0,0 +1,121 @@
+package main
+
+import (
+	"strings"
+)
+
+// TransportHotkeys are optional global keys acting on whatever clip is playing
+type TransportHotkeys struct {
+	PauseResume string  `json:"pause_resume"`
+	SeekBack    string  `json:"seek_back"`
+	SeekForward string  `json:"seek_forward"`
+	SeekStep    float64 `json:"seek_step"` // Seconds
+	StopAll     string  `json:"stop_all"`  // Fades everything out
+	Panic       string  `json:"panic"`     // Cuts everything off at once
+}
+
+const defaultSeekStep = 5.0
+
+// voicesFor returns the voices playing a clip on either bus, as of their last callback
+func (a *App) voicesFor(id string) []*voice {
+	var voices []*voice
+	for _, b := range []*bus{a.mainBus, a.auxBus} {
+		if v := b.playing.Load(); v != nil && v.id == id && v.source != nil {
+			voices = append(voices, v)
+		}
+	}
+	return voices
+}
+
+// PauseAudio pauses a playing clip where it is
+func (a *App) PauseAudio(id string) {
+	a.sendAll(command{kind: cmdPause, id: id, paused: true})
+}
+
+// ResumeAudio continues a paused clip
+func (a *App) ResumeAudio(id string) {
+	a.sendAll(command{kind: cmdPause, id: id})
+}
+
+// SeekAudio jumps a playing clip to seconds from its start
+func (a *App) SeekAudio(id string, seconds float64) {
+	voices := a.voicesFor(id)
+	if len(voices) == 0 {
+		return
+	}
+	// Positions count frames of the clip itself, so both buses take the same target
+	a.sendAll(command{kind: cmdSeek, id: id, frames: voices[0].frameAt(seconds)})
+}
+
+// StopAllAudio fades out everything that is playing
+func (a *App) StopAllAudio() {
+	a.stopAudio()
+}
+
+// PanicStop silences both outputs immediately, without a fade
+func (a *App) PanicStop() {
+	a.stopAudioNow()
+}
+
+// togglePause pauses or resumes the playing clip (pause hotkey)
+func (a *App) togglePause() {
+	id := a.playingID()
+	voices := a.voicesFor(id)
+	if len(voices) == 0 {
+		return
+	}
+	a.sendAll(command{kind: cmdPause, id: id, paused: !voices[0].source.paused.Load()})
+}
+
+// seekBy moves the playing clip by delta seconds (seek hotkeys)
+func (a *App) seekBy(delta float64) {
+	id := a.playingID()
+	voices := a.voicesFor(id)
+	if len(voices) == 0 {
+		return
+	}
+	a.SeekAudio(id, voices[0].position()+delta)
+}
+
+func (a *App) GetTransportHotkeys() TransportHotkeys {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.Config.Transport
+}
+
+// SetTransportHotkeys saves the pause/seek keys. Esc clears a key, as for clip hotkeys.
+func (a *App) SetTransportHotkeys(keys TransportHotkeys) {
+	for _, k := range []*string{&keys.PauseResume, &keys.SeekBack, &keys.SeekForward, &keys.StopAll, &keys.Panic} {
+		if strings.EqualFold(*k, "esc") || strings.EqualFold(*k, "escape") {
+			*k = ""
+		}
+	}
+	if keys.SeekStep <= 0 {
+		keys.SeekStep = defaultSeekStep
+	}
+
+	a.mu.Lock()
+	a.Config.Transport = keys
+	a.saveConfig()
+	a.mu.Unlock()
+}
+
+// checkTransportHotkeys runs a transport action if one matches. Caller holds a.mu.
+func (a *App) checkTransportHotkeys(pressedKeys map[uint16]bool) bool {
+	t := a.Config.Transport
+	switch {
+	case t.Panic != "" && a.isHotkeyPressedV2(t.Panic, pressedKeys):
+		go a.PanicStop()
+	case t.StopAll != "" && a.isHotkeyPressedV2(t.StopAll, pressedKeys):
+		go a.StopAllAudio()
+	case t.PauseResume != "" && a.isHotkeyPressedV2(t.PauseResume, pressedKeys):
+		go a.togglePause()
+	case t.SeekBack != "" && a.isHotkeyPressedV2(t.SeekBack, pressedKeys):
+		go a.seekBy(-t.SeekStep)
+	case t.SeekForward != "" && a.isHotkeyPressedV2(t.SeekForward, pressedKeys):
+		go a.seekBy(t.SeekStep)
+	default:
+		return false
+	}
+	return true
+}